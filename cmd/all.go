@@ -26,63 +26,102 @@ import (
 )
 
 var allCmd = &cobra.Command{
-	Use:   "all <user/repo>",
+	Use:   "all <user/repo> [distro/version]",
 	Short: "List all the packages in a repo",
-	Long:  `List all the packages in a repo`,
+	Long: `List all the packages in a repo
+
+With a distro/version, like ubuntu/xenial, only the packages for that distro
+version are listed. Its package type is looked up in the distributions, use
+--type to choose dsc over deb.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		repo := args[0]
-		client, err := pkgcloud.NewClient("")
+		client, err := newClient()
 		if err != nil {
-			log.Fatalf("error: %s\n", err)
+			fatalf("error: %s\n", err)
+		}
+		opts := &pkgcloud.ListOptions{PerPage: allPerPage, Prefetch: allPrefetch}
+		if len(args) > 1 {
+			pkgType := distroType(client, args[1], allType)
+			listPackages(client, client.DistroPackagesContext(rootContext, repo, pkgType, args[1], opts), allTemplateString)
+			return
 		}
-		t := template.Must(template.New("package-tmpl").Parse(allTemplateString))
+		listPackages(client, client.PackagesContext(rootContext, repo, opts), allTemplateString)
+	},
+	Args:             cobra.RangeArgs(1, 2),
+	TraverseChildren: true,
+}
 
-		next := func() (*pkgcloud.PaginatedPackages, error) {
-			return client.PaginatedAll(repo)
+// packageLister - lists packages, like pkgcloud.PackageIterator
+type packageLister interface {
+	Next() bool
+	Package() *pkgcloud.Package
+	Err() error
+}
+
+// listPackages - executes the template templateString on the packages of it, then destroys and promotes
+// the packages the template marked, unless DryRun is set
+func listPackages(client *pkgcloud.Client, it packageLister, templateString string) {
+	t := template.Must(template.New("package-tmpl").Parse(templateString))
+
+	for it.Next() {
+		pack := &Package{Package: it.Package(), client: client}
+		if err := t.Execute(os.Stdout, pack); err != nil {
+			fatalf("template error: %s\n", explain(err))
 		}
-		var packages []*pkgcloud.Package
-		for next != nil {
-			paginatedPackages, err := next()
+	}
+	if err := it.Err(); err != nil {
+		fatalf("pagination error: %s\n", explain(err))
+	}
+	if !DryRun {
+		for _, p := range packagesToDestroy {
+			err := client.DestroyFromPackageContext(rootContext, p.Package)
 			if err != nil {
-				log.Fatalf("pagination error: %s\n", err)
+				fatalf("Error when trying to Destroy %s : %s", p.PackageHTMLURL, explain(err))
 			}
-			packages = append(packages, paginatedPackages.Packages...)
-			for _, p := range paginatedPackages.Packages {
-				pack := &Package{Package: p}
-				t.Execute(os.Stdout, pack)
-			}
-			next = paginatedPackages.Next
+			log.Printf("Destroying %s\n", p.PackageHTMLURL)
 		}
-		if !DryRun {
-			for _, p := range packagesToDestroy {
-				err = client.DestroyFromPackage(p.Package)
-				if err != nil {
-					log.Fatalf("Error when trying to Destroy %s : %s", p.PackageHTMLURL, err)
-				}
-				log.Printf("Destroying %s\n", p.PackageHTMLURL)
-			}
-			for p, r := range packagesToPromote {
-				err = client.Promote(p.Package, r)
-				if err != nil {
-					log.Fatalf("Error Promoting to %s : %s : %s", r, p.PromoteURL, err)
-				}
-				log.Printf("Promoted to %s : %s\n", r, p.PromoteURL)
+		for p, r := range packagesToPromote {
+			err := client.PromoteContext(rootContext, p.Package, r)
+			if err != nil {
+				fatalf("Error Promoting to %s : %s : %s", r, p.PromoteURL, explain(err))
 			}
+			log.Printf("Promoted to %s : %s\n", r, p.PromoteURL)
 		}
-	},
-	Args:             cobra.ExactArgs(1),
-	TraverseChildren: true,
+	}
 }
 
 var allTemplateString string
 
+var allPrefetch int
+
+var allPerPage int
+
+var allType string
+
 func init() {
 	allCmd.Flags().StringVarP(&allTemplateString, "template", "t", "{{.PackageHTMLURL}}\n", "Golang text template for output")
+	allCmd.Flags().IntVar(&allPerPage, "per-page", pkgcloud.PerPageMax, "Number of packages per page (0 for the server default, -1 for the largest pages allowed)")
+	allCmd.Flags().StringVar(&allType, "type", "", "Package type of the distro/version: deb, dsc or rpm (default from the distributions)")
+	allCmd.Flags().IntVar(&allPrefetch, "prefetch", 4, "Number of pages fetched concurrently ahead of the output (0 fetches one page at a time)")
 }
 
 // Package - wraps pkgcloud.Package in order to allow adding 'convenience' method
 type Package struct {
 	*pkgcloud.Package
+	client  *pkgcloud.Client
+	details *pkgcloud.PackageDetails
+}
+
+// Details - the full description of the Package, fetched the first time it is needed
+func (p *Package) Details() (*pkgcloud.PackageDetails, error) {
+	if p.details == nil {
+		details, err := p.client.PackageDetailsContext(rootContext, p.Package)
+		if err != nil {
+			return nil, err
+		}
+		p.details = details
+	}
+	return p.details, nil
 }
 
 var packagesToPromote = make(map[*Package]string)
@@ -16,7 +16,6 @@ package cmd
 
 import (
 	"html/template"
-	"log"
 	"os"
 
 	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
@@ -26,15 +25,24 @@ import (
 var distributionsCmd = &cobra.Command{
 	Use:   "distributions",
 	Short: "List all distributions",
-	Long:  `List all distributions`,
+	Long: `List all distributions
+
+The distributions are cached for a day under the user cache dir, and used
+however old when packagecloud.io can't be reached. Use --refresh to update
+them now.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := pkgcloud.NewClient("")
+		client, err := newClient()
 		if err != nil {
-			log.Fatalf("error: %s\n", err)
+			fatalf("error: %s\n", err)
+		}
+		var distributions *pkgcloud.Distributions
+		if distributionsRefresh {
+			distributions, err = client.RefreshDistributionsContext(rootContext)
+		} else {
+			distributions, err = client.CachedDistributionsContext(rootContext)
 		}
-		distributions, err := client.Distributions()
 		if err != nil {
-			log.Fatalf("error: %s\n", err)
+			fatalf("error: %s\n", explain(err))
 		}
 		t := template.Must(template.New("package-tmpl").Parse(distributionTemplateString))
 		dist := &Distributions{Distributions: distributions}
@@ -46,7 +54,10 @@ var distributionsCmd = &cobra.Command{
 
 var distributionTemplateString string
 
+var distributionsRefresh bool
+
 func init() {
+	distributionsCmd.Flags().BoolVar(&distributionsRefresh, "refresh", false, "Fetch the distributions even if they are cached")
 	distributionsCmd.Flags().StringVarP(&distributionTemplateString, "template", "t", "{{range .Linearize}}{{.DistributionIndex}}/{{.VersionIndex}}: {{.ID}}\n{{end}}", "Golang text template for output")
 }
 
@@ -0,0 +1,179 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/debfile"
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/rpmfile"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <package file>...",
+	Short: "Show the metadata of package files",
+	Long: `Show the metadata of package files, as packagecloud.io will see it
+
+Nothing is sent to packagecloud.io: the package files are read locally.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out := newOutput(inspectTemplateString, inspectJSON)
+		var packages []*inspected
+		for _, arg := range args {
+			p, err := inspectFile(arg)
+			if err != nil {
+				fatalf("error: %s\n", err)
+			}
+			if inspectJSON {
+				packages = append(packages, p)
+				continue
+			}
+			out.write(p)
+		}
+		if inspectJSON {
+			out.write(packages)
+		}
+	},
+	Args:             cobra.MinimumNArgs(1),
+	TraverseChildren: true,
+}
+
+// inspected - the metadata of a package file
+type inspected struct {
+	// File - the path of the package file
+	File string `json:"file"`
+	// Type - the package type: deb, rpm...
+	Type string `json:"type"`
+	// Name - the name of the package
+	Name string `json:"name"`
+	// Version - the full version of the package, with epoch and release
+	Version string `json:"version"`
+	// Architecture - the architecture of the package
+	Architecture string `json:"architecture"`
+	// Depends - the dependencies of the package
+	Depends []string `json:"depends,omitempty"`
+	// Filename - the canonical filename of the package
+	Filename string `json:"filename"`
+	// DistTag - the distro the dist tag of an rpm is for, like el/7
+	DistTag string `json:"dist_tag,omitempty"`
+	// Deb - the metadata of a .deb
+	Deb *debfile.Package `json:"deb,omitempty"`
+	// Rpm - the metadata of a .rpm
+	Rpm *rpmfile.Package `json:"rpm,omitempty"`
+}
+
+// inspectFile - the metadata of the package file path
+func inspectFile(path string) (*inspected, error) {
+	pkgType := pkgcloud.PackageType(path)
+	switch pkgType {
+	case "deb":
+		deb, err := debfile.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return &inspected{
+			File:         path,
+			Type:         pkgType,
+			Name:         deb.Package,
+			Version:      deb.Version.String(),
+			Architecture: deb.Architecture,
+			Depends:      deb.Depends,
+			Filename:     deb.Filename(),
+			Deb:          deb,
+		}, nil
+	case "rpm":
+		rpm, err := rpmfile.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		arch := rpm.Arch
+		if rpm.Source {
+			arch = "src"
+		}
+		return &inspected{
+			File:         path,
+			Type:         pkgType,
+			Name:         rpm.Name,
+			Version:      rpm.EVR(),
+			Architecture: arch,
+			Depends:      rpm.Requires,
+			Filename:     rpm.Filename(),
+			DistTag:      rpm.DistTag(),
+			Rpm:          rpm,
+		}, nil
+	}
+	if pkgType == "" {
+		return nil, fmt.Errorf("%s: unknown package type", path)
+	}
+	return nil, fmt.Errorf("%s: can't inspect %s packages", path, pkgType)
+}
+
+// checkSource - checks the local package file src before it is pushed to distros: warns when it isn't
+// named the way packagecloud.io will name it, and refuses rpms built for another distro unless
+// forceDistro is set. Returns why src is refused, by distro.
+func checkSource(src *source, distros []string, forceDistro bool) map[string]error {
+	if src.Path == "" {
+		return nil
+	}
+	if pkgType := pkgcloud.PackageType(src.Path); pkgType != "deb" && pkgType != "rpm" {
+		return nil
+	}
+	p, err := inspectFile(src.Path)
+	if err != nil {
+		log.Printf("warning: %s", err)
+		return nil
+	}
+	if p.Filename != src.Name {
+		log.Printf("warning: %s is %s %s for %s, packagecloud.io will name it %s", src.Arg, p.Name, p.Version, p.Architecture, p.Filename)
+	}
+	if p.Rpm == nil {
+		return nil
+	}
+	refused := make(map[string]error)
+	for _, distro := range distros {
+		if err := p.Rpm.CheckDistro(distro); err != nil {
+			mismatch := err.(*rpmfile.DistroMismatchError)
+			if !forceDistro {
+				refused[distro] = fmt.Errorf("package %s is built for %s, not %s, use --force-distro to push it anyway", src.Arg, mismatch.DistTag, distro)
+				continue
+			}
+			log.Printf("package %s is built for %s, not %s. --force-distro provided, pushing it anyway", src.Arg, mismatch.DistTag, distro)
+		}
+	}
+	return refused
+}
+
+var inspectTemplateString string
+
+var inspectJSON bool
+
+func init() {
+	inspectCmd.Flags().StringVarP(&inspectTemplateString, "template", "t", inspectTemplate, "Golang text template for output")
+	inspectCmd.Flags().BoolVar(&inspectJSON, "json", false, "Output JSON instead of using the template")
+}
+
+const inspectTemplate = `{{.File}}:
+  Type:         {{.Type}}
+  Package:      {{.Name}}
+  Version:      {{.Version}}
+  Architecture: {{.Architecture}}
+{{- with .Depends}}
+  Depends:      {{range $i, $d := .}}{{if $i}}, {{end}}{{$d}}{{end}}{{end}}
+  Filename:     {{.Filename}}
+{{- with .DistTag}}
+  Dist tag:     {{.}}{{end}}
+`
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/gpgkey"
+	"github.com/spf13/cobra"
+)
+
+var repoKeysCmd = &cobra.Command{
+	Use:   "keys <user/repo> [keyid]",
+	Short: "List the GPG keys of a repository",
+	Long: `List the GPG keys of a repository
+
+With a key id, or the end of a fingerprint, only that key is listed. Keys
+expiring within --warn-days days are warned about.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		keys, err := client.GPGKeysContext(rootContext, args[0])
+		if err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		if len(args) > 1 {
+			keys = filterKeys(keys, args[1])
+			if len(keys) == 0 {
+				fatalf("no GPG key %s in %s", args[1], args[0])
+			}
+		}
+		out := newOutput(repoKeysTemplateString, repoJSON)
+		var infos []*keyInfo
+		for _, key := range keys {
+			info := &keyInfo{GPGKey: key}
+			if repoKeysArmor || repoKeysWarnDays > 0 {
+				info.load(client)
+			}
+			if repoKeysWarnDays > 0 {
+				info.warn(args[0], time.Now().AddDate(0, 0, repoKeysWarnDays))
+			}
+			switch {
+			case repoKeysArmor:
+				os.Stdout.Write(info.armored)
+			case repoJSON:
+				infos = append(infos, info)
+			default:
+				out.write(info)
+			}
+		}
+		if repoJSON && !repoKeysArmor {
+			out.write(infos)
+		}
+	},
+	Args:             cobra.RangeArgs(1, 2),
+	TraverseChildren: true,
+}
+
+var repoKeysAddCmd = &cobra.Command{
+	Use:   "add <user/repo> <keyfile>",
+	Short: "Upload a GPG public key to a repository",
+	Long: `Upload a GPG public key to a repository
+
+keyfile is an armored public key, as exported by 'gpg --armor --export', or
+"-" to read it from stdin.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var keydata []byte
+		var err error
+		if args[1] == "-" {
+			keydata, err = io.ReadAll(os.Stdin)
+		} else {
+			keydata, err = os.ReadFile(args[1])
+		}
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		parsed, err := gpgkey.Parse(keydata)
+		if err != nil {
+			fatalf("error: %s is not a GPG public key: %s\n", args[1], err)
+		}
+		if !bytes.Contains(keydata, []byte("-----BEGIN PGP")) {
+			keydata = gpgkey.Armor(keydata)
+		}
+		if DryRun {
+			log.Printf("Dry Run for uploading GPG key %s to %s", parsed[0].Fingerprint, args[0])
+			return
+		}
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		key, err := client.UploadGPGKeyContext(rootContext, args[0], keydata)
+		if err != nil {
+			fatalf("error uploading GPG key to %s: %s\n", args[0], explain(err))
+		}
+		log.Printf("Uploaded GPG key %s to %s", parsed[0].Fingerprint, args[0])
+		if key.KeyID != "" {
+			newOutput(repoKeysTemplateString, repoJSON).write(&keyInfo{GPGKey: key})
+		}
+	},
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+}
+
+var repoKeysRmCmd = &cobra.Command{
+	Use:     "rm <user/repo> <keyid>",
+	Aliases: []string{"delete"},
+	Short:   "Delete a GPG key from a repository",
+	Long: `Delete a GPG key from a repository
+
+The key is given by its key id, or the end of its fingerprint.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		keys, err := client.GPGKeysContext(rootContext, args[0])
+		if err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		keys = filterKeys(keys, args[1])
+		if len(keys) != 1 {
+			fatalf("%d GPG keys match %s in %s\n", len(keys), args[1], args[0])
+		}
+		if DryRun {
+			log.Printf("Dry Run for deleting GPG key %s from %s", keys[0].KeyID, args[0])
+			return
+		}
+		err = client.DestroyGPGKeyContext(rootContext, args[0], keys[0].KeyID)
+		if err != nil {
+			fatalf("error deleting GPG key %s from %s: %s\n", keys[0].KeyID, args[0], explain(err))
+		}
+		log.Printf("Deleted GPG key %s from %s", keys[0].KeyID, args[0])
+	},
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+}
+
+// keyInfo - a GPG key of a repository, with what its public key tells
+type keyInfo struct {
+	*pkgcloud.GPGKey
+	// Created - when the key was created, if known
+	Created *time.Time `json:"created,omitempty"`
+	// Expires - when the key expires, if it does
+	Expires *time.Time `json:"expires,omitempty"`
+	// UserIDs - the user ids of the key
+	UserIDs []string `json:"user_ids,omitempty"`
+
+	armored []byte
+	parsed  *gpgkey.Key
+}
+
+// load - downloads and parses the public key
+func (k *keyInfo) load(client *pkgcloud.Client) {
+	data, err := client.GPGKeyDataContext(rootContext, k.GPGKey)
+	if err != nil {
+		fatalf("error downloading GPG key %s: %s\n", k.KeyID, explain(err))
+	}
+	k.armored = data
+	if !bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		k.armored = gpgkey.Armor(data)
+	}
+	parsed, err := gpgkey.Parse(data)
+	if err != nil {
+		log.Printf("warning: can't read GPG key %s: %s", k.KeyID, err)
+		return
+	}
+	k.parsed = parsed[0]
+	k.Created = &k.parsed.Created
+	if !k.parsed.Expires.IsZero() {
+		k.Expires = &k.parsed.Expires
+	}
+	k.UserIDs = k.parsed.UserIDs
+}
+
+// warn - logs a warning when the key, or one of its subkeys, expires before t
+func (k *keyInfo) warn(repo string, t time.Time) {
+	if k.parsed == nil || !k.parsed.Expired(t) {
+		return
+	}
+	keys := append([]*gpgkey.Key{k.parsed}, k.parsed.Subkeys...)
+	for _, key := range keys {
+		if key.Expires.IsZero() || !key.Expires.Before(t) {
+			continue
+		}
+		what := "GPG key " + key.KeyID
+		if key != k.parsed {
+			what = "subkey " + key.KeyID + " of GPG key " + k.parsed.KeyID
+		}
+		if key.Expires.Before(time.Now()) {
+			log.Printf("warning: %s of %s expired on %s", what, repo, key.Expires.Format("2006-01-02"))
+		} else {
+			log.Printf("warning: %s of %s expires on %s", what, repo, key.Expires.Format("2006-01-02"))
+		}
+	}
+}
+
+// filterKeys - the keys whose key id or fingerprint ends with ref
+func filterKeys(keys []*pkgcloud.GPGKey, ref string) []*pkgcloud.GPGKey {
+	ref = strings.ToUpper(strings.TrimPrefix(strings.Replace(ref, " ", "", -1), "0x"))
+	var rv []*pkgcloud.GPGKey
+	for _, key := range keys {
+		if strings.HasSuffix(strings.ToUpper(key.KeyID), ref) || strings.HasSuffix(strings.ToUpper(key.Fingerprint), ref) {
+			rv = append(rv, key)
+		}
+	}
+	return rv
+}
+
+var repoKeysTemplateString string
+
+var repoKeysArmor bool
+
+var repoKeysWarnDays int
+
+func init() {
+	repoKeysCmd.PersistentFlags().StringVarP(&repoKeysTemplateString, "template", "t", repoKeysTemplate, "Golang text template for output")
+	repoKeysCmd.Flags().BoolVar(&repoKeysArmor, "armor", false, "Print the keys in armored form")
+	repoKeysCmd.Flags().IntVar(&repoKeysWarnDays, "warn-days", 30, "Warn about keys expiring within this many days, 0 to not check")
+	repoKeysCmd.AddCommand(repoKeysAddCmd)
+	repoKeysCmd.AddCommand(repoKeysRmCmd)
+	repoCmd.AddCommand(repoKeysCmd)
+}
+
+const repoKeysTemplate = `{{.KeyID}} {{.KeyType}} {{.Fingerprint}}{{with .Expires}} expires {{.Format "2006-01-02"}}{{end}}
+    {{.DownloadURL}}
+`
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"text/template"
+)
+
+// output - writes values to stdout as JSON, or with a Golang template
+type output struct {
+	tmpl *template.Template
+	json bool
+}
+
+// newOutput - an output using templateString, unless asJSON is set
+func newOutput(templateString string, asJSON bool) *output {
+	if asJSON {
+		return &output{json: true}
+	}
+	return &output{tmpl: template.Must(template.New("output-tmpl").Parse(templateString))}
+}
+
+// write - write v to stdout
+func (o *output) write(v interface{}) {
+	var err error
+	if o.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(v)
+	} else {
+		err = o.tmpl.Execute(os.Stdout, v)
+	}
+	if err != nil {
+		fatalf("error: %s\n", err)
+	}
+}
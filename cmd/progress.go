@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/upload"
+)
+
+const (
+	progressBarWidth    = 30
+	progressBarInterval = 100 * time.Millisecond
+	progressLogInterval = 10 * time.Second
+)
+
+// newProgress - an upload.ProgressFunc drawing a progress bar on out when it is a terminal,
+// and logging the progress periodically otherwise
+func newProgress(out *os.File) upload.ProgressFunc {
+	if isTerminal(out) {
+		return (&progressReporter{interval: progressBarInterval, report: func(p upload.Progress) {
+			fmt.Fprintf(out, "\r%s\x1b[K", progressBar(p))
+			if p.Done {
+				fmt.Fprintln(out)
+			}
+		}}).update
+	}
+	return newLogProgress()
+}
+
+// newLogProgress - an upload.ProgressFunc logging the progress periodically
+func newLogProgress() upload.ProgressFunc {
+	return (&progressReporter{interval: progressLogInterval, report: func(p upload.Progress) {
+		if p.Done {
+			log.Printf("Uploaded %s (%s, %s/s)", p.Filename, humanBytes(p.Sent), humanBytes(int64(p.Rate)))
+			return
+		}
+		log.Printf("Uploading %s: %s", p.Filename, progressSummary(p))
+	}}).update
+}
+
+// isTerminal - whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressReporter - rate limits the reports of upload progress
+type progressReporter struct {
+	mu       sync.Mutex
+	last     time.Time
+	interval time.Duration
+	report   func(upload.Progress)
+}
+
+func (r *progressReporter) update(p upload.Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !p.Done && time.Since(r.last) < r.interval {
+		return
+	}
+	r.last = time.Now()
+	r.report(p)
+}
+
+// progressBar - a single line progress bar like "foo.deb [=====>    ]  50% 1.0 MiB/2.0 MiB 512.0 KiB/s"
+func progressBar(p upload.Progress) string {
+	if p.Total <= 0 {
+		return fmt.Sprintf("%s %s", p.Filename, progressSummary(p))
+	}
+	filled := int(int64(progressBarWidth) * p.Sent / p.Total)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return fmt.Sprintf("%s [%s] %s", p.Filename, bar, progressSummary(p))
+}
+
+// progressSummary - the amount sent and the rate of p, as text
+func progressSummary(p upload.Progress) string {
+	if p.Total <= 0 {
+		return fmt.Sprintf("%s %s/s", humanBytes(p.Sent), humanBytes(int64(p.Rate)))
+	}
+	return fmt.Sprintf("%3d%% %s/%s %s/s", 100*p.Sent/p.Total, humanBytes(p.Sent), humanBytes(p.Total), humanBytes(int64(p.Rate)))
+}
+
+// humanBytes - n bytes in binary units, e.g. "1.5 MiB"
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
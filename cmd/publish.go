@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish [-m publish.yaml]",
+	Short: "Push the packages listed in a manifest",
+	Long: `Push the packages listed in a manifest
+
+The manifest maps package files to the targets they are pushed to, like:
+
+  jobs: 4
+  skip-identical: true
+  packages:
+    - build/xenial/*.deb -> fdio/release/ubuntu/xenial
+    - files: [build/el7/*.rpm, build/noarch/]
+      to: fdio/release/el/7
+      force: true
+
+Files and targets are those of 'pkgcloud push': files can be directories and
+glob patterns, relative to the directory of the manifest, and targets can have
+wildcards and alternatives. force, skip-identical and force-distro can be set
+for all the packages at the top of the manifest, and for each entry.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := loadManifest(publishManifest)
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		jobs := publishJobs
+		if m.Jobs != 0 && !cmd.Flags().Changed("jobs") {
+			jobs = m.Jobs
+		}
+		client := newPushClient(jobs)
+		var queue []pushJob
+		for i, entry := range m.Packages {
+			var targets []pushTarget
+			for _, to := range entry.To {
+				t, err := parsePushTargets(to)
+				if err != nil {
+					fatalf("%s: package entry %d: %s", publishManifest, i+1, err)
+				}
+				targets = append(targets, t...)
+			}
+			queue = append(queue, newPushJobs(client, targets, m.files(entry), m.options(entry))...)
+		}
+		queue = uniquePushJobs(queue)
+		if len(queue) == 0 {
+			fatalf("%s: no package to publish", publishManifest)
+		}
+		runPushJobs(client, queue, jobs)
+	},
+	Args:             cobra.NoArgs,
+	TraverseChildren: true,
+}
+
+// manifest - the packages publish pushes, and how
+type manifest struct {
+	// Jobs - number of packages checked and pushed concurrently, 0 for the default of --jobs
+	Jobs int `yaml:"jobs"`
+	// Force, SkipIdentical and ForceDistro - the default options of the entries
+	Force         bool `yaml:"force"`
+	SkipIdentical bool `yaml:"skip-identical"`
+	ForceDistro   bool `yaml:"force-distro"`
+	// Packages - the package files and their targets
+	Packages []manifestEntry `yaml:"packages"`
+
+	// dir - the directory of the manifest, relative files are in
+	dir string
+}
+
+// manifestEntry - package files and the targets they are pushed to. Options that aren't set default to
+// those of the manifest.
+type manifestEntry struct {
+	Files         stringList `yaml:"files"`
+	To            stringList `yaml:"to"`
+	Force         *bool      `yaml:"force"`
+	SkipIdentical *bool      `yaml:"skip-identical"`
+	ForceDistro   *bool      `yaml:"force-distro"`
+}
+
+// UnmarshalYAML - reads an entry either as a mapping, or as a string like "build/*.deb -> user/repo/ubuntu/xenial",
+// where files and targets are separated by spaces
+func (e *manifestEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		parts := strings.Split(s, "->")
+		if len(parts) != 2 {
+			return fmt.Errorf("%q is not of form files -> targets", s)
+		}
+		*e = manifestEntry{Files: strings.Fields(parts[0]), To: strings.Fields(parts[1])}
+		return nil
+	}
+	type plain manifestEntry
+	return unmarshal((*plain)(e))
+}
+
+// stringList - a list of strings, which can be written as a single string in manifests
+type stringList []string
+
+// UnmarshalYAML - reads a list of strings, or a single string
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	return unmarshal((*[]string)(l))
+}
+
+// loadManifest - the manifest at path
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{dir: filepath.Dir(path)}
+	if err := yaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if m.Jobs < 0 {
+		return nil, fmt.Errorf("%s: jobs must not be negative, 0 means the default of --jobs", path)
+	}
+	for i, entry := range m.Packages {
+		if len(entry.Files) == 0 || len(entry.To) == 0 {
+			return nil, fmt.Errorf("%s: package entry %d: files and to are required", path, i+1)
+		}
+	}
+	return m, nil
+}
+
+// files - the files of entry, relative to the current directory rather than to the manifest
+func (m *manifest) files(entry manifestEntry) []string {
+	files := make([]string, 0, len(entry.Files))
+	for _, f := range entry.Files {
+		if !filepath.IsAbs(f) && !strings.Contains(f, "://") {
+			f = filepath.Join(m.dir, f)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// options - the push options of entry
+func (m *manifest) options(entry manifestEntry) pushOptions {
+	opts := pushOptions{Force: m.Force, SkipIdentical: m.SkipIdentical, ForceDistro: m.ForceDistro}
+	if entry.Force != nil {
+		opts.Force = *entry.Force
+	}
+	if entry.SkipIdentical != nil {
+		opts.SkipIdentical = *entry.SkipIdentical
+	}
+	if entry.ForceDistro != nil {
+		opts.ForceDistro = *entry.ForceDistro
+	}
+	return opts
+}
+
+// uniquePushJobs - jobs without the ones pushing a package file to a repo and distro an earlier job already
+// pushes it to. The files of different entries may overlap, e.g. a directory and a pattern under it.
+func uniquePushJobs(jobs []pushJob) []pushJob {
+	type pushKey struct {
+		file, repo, distro string
+	}
+	seen := make(map[pushKey]bool)
+	var rv []pushJob
+	for _, job := range jobs {
+		file := job.Source.Arg
+		if job.Source.Path != "" {
+			file = filepath.Clean(job.Source.Path)
+			if abs, err := filepath.Abs(file); err == nil {
+				file = abs
+			}
+		}
+		key := pushKey{file: file, repo: job.Repo, distro: job.Distro}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rv = append(rv, job)
+	}
+	return rv
+}
+
+var publishManifest string
+
+var publishJobs int
+
+func init() {
+	publishCmd.Flags().StringVarP(&publishManifest, "manifest", "m", "publish.yaml", "Manifest listing the packages to publish")
+	publishCmd.Flags().IntVarP(&publishJobs, "jobs", "j", 1, "Number of packages checked and pushed concurrently, overrides the manifest")
+}
@@ -15,74 +15,57 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"text/tabwriter"
 
 	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
 	"github.com/spf13/cobra"
 )
 
 var pushCmd = &cobra.Command{
-	Use:   "push user/repo/distro/version/ filename",
+	Use:   "push user/repo/distro/version/... filename|-|url...",
 	Short: "push package to repo",
-	Long:  `push package to repo`,
+	Long: `push package to repo
+
+Packages can be local files, - to read a package from stdin (with --filename),
+or file://, http:// and https:// URLs, which are streamed into the upload.
+Directories stand for the package files under them, recursively, and glob
+patterns, where ** matches any number of directories, for the files they match.
+
+Packages are pushed to each of the targets before them. Distros can have wildcards
+matched against the distributions, like user/repo/ubuntu/*, and alternatives, like
+user/repo/{ubuntu/xenial,debian/stretch}. Quote them so that the shell leaves them be.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		parts := strings.Split(args[0], "/")
-		if len(parts) != 4 {
-			log.Fatalf("%s is not of form user/repo/distro/version/", args[0])
-		}
-		repo := parts[0] + "/" + parts[1]
-		distro := parts[2] + "/" + parts[3]
-		client, err := pkgcloud.NewClient("")
-		if err != nil {
-			log.Fatalf("error: %s\n", err)
-		}
-		for i := 1; i < len(args); i++ {
-			if _, err := os.Stat(args[i]); os.IsNotExist(err) {
-				log.Fatalf("%s does not exist", args[i])
+		var targets []pushTarget
+		n := 0
+		for ; n < len(args) && (n == 0 || isPushTarget(args[n])); n++ {
+			t, err := parsePushTargets(args[n])
+			if err != nil {
+				fatalf("%s", err)
 			}
+			targets = append(targets, t...)
 		}
-		for i := 1; i < len(args); i++ {
-			repodistro := fmt.Sprintf("%s/%s", repo, distro)
-			path := args[i]
-			filename := filepath.Base(path)
-			if !DryRun {
-				exists, err := client.Exists(repo, distro, filename)
-				if err != nil {
-					log.Fatalf("error: %s\n", err)
-				}
-				if exists {
-					if !force {
-						log.Fatalf("package %s already exists in repo %s/%s, use -f to force overwrite", filename, repo, distro)
-					}
-					log.Printf("package %s already exists in repo %s/%s. -f provided.  Deleting in preparation to push new version", filename, repo, distro)
-					err = client.Destroy(repodistro, filename)
-					if err != nil {
-						log.Fatalf("error deleting %s from %s in preparation for overwrite: %s\n", filename, repodistro, err)
-					}
-				}
-				err = client.CreatePackage(repo, distro, path)
-				if err != nil {
-					log.Fatalf("error: %s\n", err)
-				}
-				log.Printf("Pushed %s to %s", path, repodistro)
-			} else {
-				exists, err := client.Exists(repo, distro, filename)
-				if err != nil {
-					log.Fatalf("error: %s\n", err)
-				}
-				if exists {
-					if !force {
-						log.Fatalf("Dry Run package %s already exists in repo %s/%s, use -f to force overwrite", filename, repo, distro)
-					}
-					log.Printf("Dry Run package %s already exists in repo %s/%s. -f provided.  Deleting in preparation to push new version", filename, repo, distro)
-				}
-				log.Printf("Dry Run for pushing %s to %s", args[i], repodistro)
-			}
+		if n == len(args) {
+			fatalf("no package to push")
+		}
+		client := newPushClient(pushJobs)
+		opts := pushOptions{
+			Force:         force,
+			SkipIdentical: pushSkipIdentical,
+			ForceDistro:   pushForceDistro,
+			Filename:      pushFilename,
 		}
+		jobs := newPushJobs(client, targets, args[n:], opts)
+		runPushJobs(client, jobs, pushJobs)
 	},
 	Args:             cobra.MinimumNArgs(2),
 	TraverseChildren: true,
@@ -90,6 +73,332 @@ var pushCmd = &cobra.Command{
 
 var force bool
 
+var pushFilename string
+
+var pushForceDistro bool
+
+var pushSkipIdentical bool
+
+var pushJobs int
+
 func init() {
 	pushCmd.Flags().BoolVarP(&force, "force", "f", false, "Force overwrite of package if it already exists")
+	pushCmd.Flags().BoolVar(&pushForceDistro, "force-distro", false, "Push rpms even if their dist tag is for another distro")
+	pushCmd.Flags().BoolVar(&pushSkipIdentical, "skip-identical", false, "Skip packages that already exist with the same contents")
+	pushCmd.Flags().IntVarP(&pushJobs, "jobs", "j", 1, "Number of packages checked and pushed concurrently")
+	pushCmd.Flags().StringVar(&pushFilename, "filename", "", "Filename of the package read from stdin")
+}
+
+// pushOptions - how packages are pushed
+type pushOptions struct {
+	// Force - replace packages that already exist
+	Force bool
+	// SkipIdentical - skip packages that already exist with the same contents
+	SkipIdentical bool
+	// ForceDistro - push rpms even if their dist tag is for another distro
+	ForceDistro bool
+	// Filename - the filename of a package read from stdin
+	Filename string
+}
+
+// pushJob - a package to push to a repo and distro
+type pushJob struct {
+	Source  *source
+	Repo    string
+	Distro  string
+	Options pushOptions
+	// Refused - why the package must not be pushed to Distro, found when checking it
+	Refused error
+}
+
+// newPushClient - the client pushing packages, jobs at a time
+func newPushClient(jobs int) *pkgcloud.Client {
+	if jobs < 1 {
+		fatalf("--jobs must be at least 1")
+	}
+	policy := retryPolicy()
+	// Retrying an upload is safe: packagecloud rejects a package it already has
+	policy.RetryNonIdempotent = true
+	progress := newProgress(os.Stdout)
+	if jobs > 1 {
+		// The progress bars of concurrent uploads would overwrite each other
+		progress = newLogProgress()
+	}
+	client, err := newClient(pkgcloud.WithRetryPolicy(policy), pkgcloud.WithProgress(progress))
+	if err != nil {
+		fatalf("error: %s\n", err)
+	}
+	return client
+}
+
+// newPushJobs - the jobs pushing the packages named by args to targets with opts, once per distro.
+// Directories and glob patterns in args are expanded to the package files they stand for.
+func newPushJobs(client *pkgcloud.Client, targets []pushTarget, args []string, opts pushOptions) []pushJob {
+	var resolver *pkgcloud.DistroResolver
+	for _, t := range targets {
+		if hasWildcards(t.Distro) {
+			var err error
+			if resolver, err = client.DistroResolverContext(rootContext); err != nil {
+				fatalf("error: %s\n", explain(err))
+			}
+			break
+		}
+	}
+	var jobs []pushJob
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		paths, err := expandPackageArg(arg)
+		if err != nil {
+			fatalf("%s", err)
+		}
+		for _, path := range paths {
+			// Directories and patterns may overlap
+			if seen[filepath.Clean(path)] {
+				continue
+			}
+			seen[filepath.Clean(path)] = true
+			src, err := openSource(rootContext, path, opts.Filename)
+			if err != nil {
+				fatalf("%s", err)
+			}
+			srcJobs, err := sourcePushJobs(src, targets, resolver, opts)
+			if err != nil {
+				fatalf("%s: %s", src.Arg, err)
+			}
+			if src.Arg == "-" && len(srcJobs) > 1 {
+				fatalf("a package read from stdin can only be pushed to a single distro")
+			}
+			if opts.SkipIdentical && src.Arg == "-" && src.Size < 0 {
+				fatalf("--skip-identical can't read a package from a pipe twice, redirect it from a file instead")
+			}
+			var distros []string
+			for _, job := range srcJobs {
+				distros = append(distros, job.Distro)
+			}
+			refused := checkSource(src, distros, opts.ForceDistro)
+			for i := range srcJobs {
+				srcJobs[i].Refused = refused[srcJobs[i].Distro]
+			}
+			jobs = append(jobs, srcJobs...)
+		}
+	}
+	return jobs
+}
+
+// sourcePushJobs - the jobs pushing src to targets with opts, once per distro. resolver expands the wildcards
+// of targets, it may be nil when there are none.
+func sourcePushJobs(src *source, targets []pushTarget, resolver *pkgcloud.DistroResolver, opts pushOptions) ([]pushJob, error) {
+	var jobs []pushJob
+	seen := make(map[pushTarget]bool)
+	for _, t := range targets {
+		distros, err := t.distros(resolver, pkgcloud.PackageType(src.Name))
+		if err != nil {
+			return nil, err
+		}
+		for _, distro := range distros {
+			if key := (pushTarget{Repo: t.Repo, Distro: distro}); !seen[key] {
+				seen[key] = true
+				jobs = append(jobs, pushJob{Source: src, Repo: t.Repo, Distro: distro, Options: opts})
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// runPushJobs - runs jobs, n at a time, and reports the results
+func runPushJobs(client *pkgcloud.Client, jobs []pushJob, n int) {
+	var wg sync.WaitGroup
+	results := make([]*pushResult, len(jobs))
+	indexes := make(chan int)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = pushPackage(client, jobs[i])
+				if results[i].Err != nil && len(jobs) > 1 {
+					log.Printf("Failed to push %s to %s: %s", results[i].Arg, results[i].Target, results[i].Err)
+				}
+			}
+		}()
+	}
+	for i := range jobs {
+		if rootContext.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if rootContext.Err() != nil {
+		fatalf("interrupted")
+	}
+	reportPushResults(results)
+}
+
+// Statuses of pushResult
+const (
+	pushPushed  = "pushed"
+	pushSkipped = "skipped"
+	pushDryRun  = "dry run"
+	pushFailed  = "failed"
+)
+
+// pushResult - what became of a package pushed to a repo/distro
+type pushResult struct {
+	// Arg - the command line argument naming the package
+	Arg string
+	// Target - the repo/distro the package is pushed to
+	Target string
+	// Status - pushPushed, pushSkipped when unchanged, pushDryRun or pushFailed
+	Status string
+	// Err - why the package failed to be pushed
+	Err error
+}
+
+// pushPackage - run job, logging what is done. Failures are returned rather than fatal,
+// so that the other packages are pushed all the same.
+func pushPackage(client *pkgcloud.Client, job pushJob) *pushResult {
+	src, repo, distro, opts := job.Source, job.Repo, job.Distro, job.Options
+	repodistro := fmt.Sprintf("%s/%s", repo, distro)
+	filename := src.Name
+	result := &pushResult{Arg: src.Arg, Target: repodistro}
+	fail := func(format string, v ...interface{}) *pushResult {
+		result.Status, result.Err = pushFailed, fmt.Errorf(format, v...)
+		return result
+	}
+	if job.Refused != nil {
+		return fail("%s", job.Refused)
+	}
+	dryRun := ""
+	if DryRun {
+		dryRun = "Dry Run "
+	}
+	exists, identical, err := pushedBefore(client, repo, distro, src, opts.SkipIdentical)
+	if errors.Is(err, errNoSum) && opts.Force {
+		// It's overwritten anyway, what can't be compared is taken to differ
+		log.Printf("%s%s, overwriting it", dryRun, err)
+		err = nil
+	}
+	if err != nil {
+		return fail("%s", explain(err))
+	}
+	if identical {
+		log.Printf("%s%s unchanged in %s", dryRun, src.Arg, repodistro)
+		result.Status = pushSkipped
+		return result
+	}
+	if exists {
+		if !opts.Force {
+			return fail("%spackage %s already exists in repo %s%s, use -f to force overwrite", dryRun, filename, repodistro, differs(opts))
+		}
+		log.Printf("%spackage %s already exists in repo %s. -f provided.  Deleting in preparation to push new version", dryRun, filename, repodistro)
+		if !DryRun {
+			if err := client.DestroyContext(rootContext, repodistro, filename); err != nil {
+				return fail("error deleting %s from %s in preparation for overwrite: %s", filename, repodistro, explain(err))
+			}
+		}
+	}
+	if DryRun {
+		log.Printf("Dry Run for pushing %s to %s", src.Arg, repodistro)
+		result.Status = pushDryRun
+		return result
+	}
+	err = client.UploadPackageContext(rootContext, repo, distro, src.File)
+	if errors.Is(err, pkgcloud.ErrConflict) {
+		// Uploads are retried, so the conflict may be with an earlier attempt of ours whose response was lost
+		if _, identical, lookupErr := pushedBefore(client, repo, distro, src, true); lookupErr != nil || !identical {
+			return fail("package %s already exists in repo %s, use -f to force overwrite", filename, repodistro)
+		}
+		err = nil
+	}
+	if err != nil {
+		return fail("%s", explain(err))
+	}
+	log.Printf("Pushed %s to %s", src.Arg, repodistro)
+	result.Status = pushPushed
+	return result
+}
+
+// reportPushResults - exits with the error of the package that failed to be pushed, if any. When several packages
+// were pushed, a summary table is printed if any failed, and exits non-zero.
+func reportPushResults(results []*pushResult) {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	if len(results) == 1 {
+		if results[0].Err != nil {
+			fatalf("%s", results[0].Err)
+		}
+		return
+	}
+	if counts[pushFailed] == 0 {
+		var summary []string
+		for _, status := range []string{pushPushed, pushSkipped, pushDryRun} {
+			if counts[status] > 0 {
+				summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+			}
+		}
+		log.Printf("Done: %s", strings.Join(summary, ", "))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "PACKAGE\tTARGET\tSTATUS\tERROR\n")
+	for _, r := range results {
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Arg, r.Target, r.Status, errText)
+	}
+	w.Flush()
+	fatalf("%d of %d failed to push", counts[pushFailed], len(results))
+}
+
+// errNoSum - the error of pushedBefore when the package that exists has no sha256 sum to compare with
+var errNoSum = errors.New("no sha256 sum to compare it with")
+
+// pushedBefore - whether a package named like src already exists in repo/distro and, with skipIdentical,
+// whether its contents are those of src
+func pushedBefore(client *pkgcloud.Client, repo, distro string, src *source, skipIdentical bool) (exists, identical bool, err error) {
+	p, exists, err := client.LookupPackageContext(rootContext, repo, distro, src.Name)
+	if err != nil || !exists || !skipIdentical {
+		return exists, false, err
+	}
+	details, err := client.PackageDetailsContext(rootContext, p)
+	if err != nil {
+		return true, false, err
+	}
+	sum := details.FileSha256(src.Name)
+	if sum == "" {
+		return true, false, fmt.Errorf("%s in %s/%s: %w", src.Name, repo, distro, errNoSum)
+	}
+	local, err := sha256Sum(src)
+	if err != nil {
+		return true, false, err
+	}
+	return true, strings.EqualFold(local, sum), nil
+}
+
+// differs - how a package that already exists differs from the one pushed, as far as we know
+func differs(opts pushOptions) string {
+	if opts.SkipIdentical {
+		return " with different contents"
+	}
+	return ""
+}
+
+// sha256Sum - the hex encoded sha256 sum of the contents of src. Remote sources are downloaded to compute it.
+func sha256Sum(src *source) (string, error) {
+	r, err := src.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("%s: %s", src.Arg, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/spf13/cobra"
+)
+
+var repoCmd = &cobra.Command{
+	Use:              "repo",
+	Short:            "Manage repositories",
+	Long:             `Manage repositories`,
+	TraverseChildren: true,
+}
+
+var repoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your repositories",
+	Long:  `List the repositories of the user owning the API token`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		out := newOutput(repoListTemplateString, repoJSON)
+		var repositories []*pkgcloud.Repository
+		it := client.RepositoriesContext(rootContext, &pkgcloud.ListOptions{PerPage: pkgcloud.PerPageMax})
+		for it.Next() {
+			if repoJSON {
+				repositories = append(repositories, it.Item())
+				continue
+			}
+			out.write(it.Item())
+		}
+		if err := it.Err(); err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		if repoJSON {
+			out.write(repositories)
+		}
+	},
+	Args:             cobra.ExactArgs(0),
+	TraverseChildren: true,
+}
+
+var repoShowCmd = &cobra.Command{
+	Use:   "show <user/repo>",
+	Short: "Show a repository",
+	Long:  `Show a repository`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		repository, err := client.RepositoryContext(rootContext, args[0])
+		if err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		newOutput(repoShowTemplateString, repoJSON).write(repository)
+	},
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+}
+
+var repoCreateCmd = &cobra.Command{
+	Use:   "create <[user/]repo>",
+	Short: "Create a repository",
+	Long: `Create a repository
+
+The repository is created for the user owning the API token, so the user part
+of user/repo, if given, must be that user.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		if DryRun {
+			log.Printf("Dry Run for creating repository %s (private: %t)", args[0], repoPrivate)
+			return
+		}
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		repository, err := client.CreateRepositoryContext(rootContext, name, repoPrivate)
+		if err != nil {
+			fatalf("error creating repository %s: %s\n", args[0], explain(err))
+		}
+		log.Printf("Created repository %s", args[0])
+		if repository.FQName != "" {
+			newOutput(repoShowTemplateString, repoJSON).write(repository)
+		}
+	},
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+}
+
+var repoJSON bool
+
+var repoListTemplateString string
+
+var repoShowTemplateString string
+
+var repoPrivate bool
+
+func init() {
+	repoCmd.PersistentFlags().BoolVar(&repoJSON, "json", false, "Output JSON instead of using the template")
+	repoListCmd.Flags().StringVarP(&repoListTemplateString, "template", "t", "{{.FQName}}\n", "Golang text template for output")
+	repoShowCmd.Flags().StringVarP(&repoShowTemplateString, "template", "t", repoShowTemplate, "Golang text template for output")
+	repoCreateCmd.Flags().StringVarP(&repoShowTemplateString, "template", "t", repoShowTemplate, "Golang text template for output")
+	repoCreateCmd.Flags().BoolVar(&repoPrivate, "private", false, "Create a private repository")
+	repoCmd.AddCommand(repoListCmd)
+	repoCmd.AddCommand(repoShowCmd)
+	repoCmd.AddCommand(repoCreateCmd)
+}
+
+const repoShowTemplate = `Name:     {{.FQName}}
+URL:      {{.URL}}
+Private:  {{.Private}}
+Created:  {{.CreatedAt}}
+Packages: {{.PackageCountHuman}}
+Pushed:   {{.LastPushHuman}}
+`
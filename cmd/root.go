@@ -15,15 +15,34 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
 	"github.com/spf13/cobra"
 )
 
 // DryRun - if true, don't do anything that would change packagecloud.io state
 var DryRun bool
 
+// rootContext - cancelled on SIGINT/SIGTERM, so commands can stop in-flight requests
+var rootContext = context.Background()
+
+// URL - if set, the base URL of the packagecloud instance to talk to
+var URL string
+
+// Retries - number of times requests failing with transient errors are retried
+var Retries int
+
+// RetryMaxWait - longest wait between two attempts of a request
+var RetryMaxWait time.Duration
+
 var rootCmd = &cobra.Command{
 	Use:   "pkgcloud",
 	Short: "pkgcloud is a command-line for packagecloud.io",
@@ -36,15 +55,73 @@ var rootCmd = &cobra.Command{
 
 // Execute the pkgcloud command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore the default behavior so that a second signal kills us immediately
+		<-ctx.Done()
+		stop()
+	}()
+	rootContext = ctx
+	err := rootCmd.Execute()
+	stop()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+// explain - the message of err, with a hint for API errors the user can do something about
+func explain(err error) string {
+	switch {
+	case errors.Is(err, pkgcloud.ErrUnauthorized):
+		return fmt.Sprintf("%s (check PACKAGECLOUD_TOKEN or ~/.packagecloud)", err)
+	case errors.Is(err, pkgcloud.ErrForbidden):
+		return fmt.Sprintf("%s (the token has no access to this repo)", err)
+	case errors.Is(err, pkgcloud.ErrRateLimited):
+		return fmt.Sprintf("%s (rate limited, see --retries and --retry-max-wait)", err)
+	}
+	return err.Error()
+}
+
+// fatalf - like log.Fatalf, but reports commands cancelled by a signal as interrupted
+func fatalf(format string, v ...interface{}) {
+	if rootContext.Err() != nil {
+		log.Println("interrupted")
+		os.Exit(130)
+	}
+	log.Fatalf(format, v...)
+}
+
+// retryPolicy - the pkgcloud.RetryPolicy described by the root flags
+func retryPolicy() pkgcloud.RetryPolicy {
+	policy := pkgcloud.DefaultRetryPolicy
+	policy.MaxRetries = Retries
+	policy.MaxWait = RetryMaxWait
+	return policy
+}
+
+// newClient - create a pkgcloud.Client honoring the root flags, followed by opts
+func newClient(opts ...pkgcloud.Option) (*pkgcloud.Client, error) {
+	opts = append([]pkgcloud.Option{pkgcloud.WithRetryPolicy(retryPolicy())}, opts...)
+	if URL != "" {
+		opts = append(opts, pkgcloud.WithURL(URL))
+	}
+	return pkgcloud.New(opts...)
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&DryRun, "dry-run", "d", false, "Do not take actions that change the state of packagecloud.io")
+	rootCmd.PersistentFlags().IntVar(&Retries, "retries", pkgcloud.DefaultRetryPolicy.MaxRetries, "Number of times requests failing with transient errors are retried")
+	rootCmd.PersistentFlags().DurationVar(&RetryMaxWait, "retry-max-wait", pkgcloud.DefaultRetryPolicy.MaxWait, "Longest wait between two attempts of a request")
+	rootCmd.PersistentFlags().StringVar(&URL, "url", "", "Base URL of the packagecloud instance (default $PACKAGECLOUD_URL or https://packagecloud.io/)")
 	rootCmd.AddCommand(allCmd)
 	rootCmd.AddCommand(distributionsCmd)
+	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(publishCmd)
 	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(repoCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(tokenCmd)
+	rootCmd.AddCommand(versionsCmd)
 }
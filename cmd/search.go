@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <user/repo> <query>",
+	Short: "Search the packages in a repo",
+	Long: `Search the packages in a repo
+
+The search is done by packagecloud.io, on the package names. The packages found
+are output with a template, just like 'pkgcloud all' does, including the Promote
+and Destroy methods.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchType != "" && !contains(searchTypes, searchType) {
+			fatalf("unknown --type %q, use one of %s", searchType, strings.Join(searchTypes, ", "))
+		}
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		query := pkgcloud.SearchQuery{
+			Query: args[1],
+			Type:  searchType,
+			Dist:  searchDist,
+		}
+		listPackages(client, client.SearchContext(rootContext, args[0], query, &pkgcloud.ListOptions{PerPage: searchPerPage, Prefetch: searchPrefetch}), searchTemplateString)
+	},
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+}
+
+// searchTypes - the package types packagecloud.io knows of
+var searchTypes = []string{"deb", "rpm", "dsc", "gem", "python", "node", "java", "anyfile"}
+
+// contains - whether s is one of list
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+var searchTemplateString string
+
+var searchPerPage int
+
+var searchPrefetch int
+
+var searchType string
+
+var searchDist string
+
+func init() {
+	searchCmd.Flags().StringVarP(&searchTemplateString, "template", "t", "{{.PackageHTMLURL}}\n", "Golang text template for output")
+	searchCmd.Flags().StringVar(&searchType, "type", "", "Only search packages of this type ("+strings.Join(searchTypes, "|")+")")
+	searchCmd.Flags().StringVar(&searchDist, "dist", "", "Only search packages for this distro, e.g. ubuntu or ubuntu/xenial")
+	searchCmd.Flags().IntVar(&searchPerPage, "per-page", pkgcloud.PerPageMax, "Number of packages per page (0 for the server default, -1 for the largest pages allowed)")
+	searchCmd.Flags().IntVar(&searchPrefetch, "prefetch", 4, "Number of pages fetched concurrently ahead of the output (0 fetches one page at a time)")
+}
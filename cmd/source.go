@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/upload"
+)
+
+// source - a package to push, as given on the command line
+type source struct {
+	// Arg - the command line argument naming the source
+	Arg string
+	// Path - the path of a local package file, empty for other sources
+	Path string
+	upload.File
+}
+
+// openSource - the package named by arg: a local path, "-" for stdin,
+// or a file://, http:// or https:// URL. filename names packages read from stdin.
+func openSource(ctx context.Context, arg, filename string) (*source, error) {
+	if arg == "-" {
+		if filename == "" {
+			return nil, fmt.Errorf("--filename is required to push from stdin")
+		}
+		var size int64 = -1
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+		}
+		return &source{Arg: arg, File: upload.ReaderFile(filename, os.Stdin, size)}, nil
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.Scheme == "" {
+		return localSource(arg)
+	}
+	switch u.Scheme {
+	case "file":
+		return localSource(u.Path)
+	case "http", "https":
+		return remoteSource(ctx, arg, u)
+	}
+	// Probably a local path that happens to contain a colon
+	return localSource(arg)
+}
+
+// localSource - the package at path
+func localSource(path string) (*source, error) {
+	file, err := upload.LocalFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &source{Arg: path, Path: path, File: file}, nil
+}
+
+// remoteSource - the package at u, streamed from the web server while it is pushed.
+// The package is downloaded again if the upload is retried.
+func remoteSource(ctx context.Context, arg string, u *url.URL) (*source, error) {
+	get := func(method string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, arg, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s: %s", method, arg, resp.Status)
+		}
+		return resp, nil
+	}
+	// The size is only used for the Content-Length of the upload, so don't insist
+	// on servers answering HEAD requests, like ones serving presigned URLs
+	var size int64 = -1
+	if resp, err := get("HEAD"); err == nil {
+		resp.Body.Close()
+		size = resp.ContentLength
+	}
+	return &source{
+		Arg: arg,
+		File: upload.File{
+			Name: path.Base(u.Path),
+			Size: size,
+			Open: func() (io.ReadCloser, error) {
+				resp, err := get("GET")
+				if err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		},
+	}, nil
+}
+
+// expandPackageArg - the packages arg stands for: the package files under arg, recursively, when it's
+// a directory, the files matching arg when it's a glob pattern, where ** matches any number of directories,
+// and arg itself otherwise
+func expandPackageArg(arg string) ([]string, error) {
+	if arg == "-" || strings.Contains(arg, "://") {
+		return []string{arg}, nil
+	}
+	if info, err := os.Stat(arg); err == nil {
+		if !info.IsDir() {
+			return []string{arg}, nil
+		}
+		paths, err := packageFiles(arg, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no package files in %s", arg)
+		}
+		return paths, nil
+	}
+	if !hasWildcards(arg) {
+		return []string{arg}, nil
+	}
+	paths, err := globFiles(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no file matches %s", arg)
+	}
+	return paths, nil
+}
+
+// packageFiles - the package files under dir, recursively, whose path relative to dir match matches when
+// it's not nil, sorted. Package files are those of a known package type, see pkgcloud.PackageType.
+func packageFiles(dir string, match func(rel string) bool) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if match != nil && !match(filepath.ToSlash(rel)) {
+			return nil
+		}
+		if match == nil && pkgcloud.PackageType(path) == "" {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+// globFiles - the files matching pattern, sorted. Unlike with filepath.Glob, ** matches any number of
+// directories, and directories don't match.
+func globFiles(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+				paths = append(paths, m)
+			}
+		}
+		return paths, nil
+	}
+	// Walk the directory before the first wildcard, matching the rest of the pattern
+	segments := strings.Split(pattern, "/")
+	n := 0
+	for n < len(segments)-1 && !hasWildcards(segments[n]) {
+		n++
+	}
+	dir := strings.Join(segments[:n], "/")
+	if dir == "" {
+		dir = "."
+		if strings.HasPrefix(pattern, "/") {
+			dir = "/"
+		}
+	}
+	rest := segments[n:]
+	for _, segment := range rest {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %s", pattern, err)
+		}
+	}
+	paths, err := packageFiles(dir, func(rel string) bool {
+		return matchSegments(rest, strings.Split(rel, "/"))
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return paths, err
+}
+
+// matchSegments - whether the path segments name match the pattern segments pattern,
+// where a ** segment matches any number of segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
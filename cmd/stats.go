@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats <user/repo>",
+	Short: "Show download statistics of the packages in a repo",
+	Long: `Show download statistics of the packages in a repo
+
+Downloads are summed per package name, version and distro, over all architectures.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := args[0]
+		dates, err := statsDateRange()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		switch statsOutput {
+		case "table", "csv", "json":
+		default:
+			fatalf("unknown --output %q, use table, csv or json", statsOutput)
+		}
+		if statsJobs < 1 {
+			fatalf("--jobs must be at least 1")
+		}
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		ctx, cancel := context.WithCancel(rootContext)
+		defer cancel()
+
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			firstErr error
+			counts   = make(map[statsRow]int)
+		)
+		packages := make(chan *pkgcloud.Package)
+		for i := 0; i < statsJobs; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for p := range packages {
+					count, err := client.DownloadCountContext(ctx, p, dates)
+					mu.Lock()
+					if err != nil && firstErr == nil {
+						firstErr = fmt.Errorf("%s: %s", p.PackageHTMLURL, explain(err))
+						cancel()
+					}
+					counts[newStatsRow(p)] += count
+					mu.Unlock()
+				}
+			}()
+		}
+		it := client.PackagesContext(ctx, repo, &pkgcloud.ListOptions{PerPage: pkgcloud.PerPageMax, Prefetch: 4})
+		for it.Next() {
+			if statsName != "" && it.Package().Name != statsName {
+				continue
+			}
+			select {
+			case packages <- it.Package():
+			case <-ctx.Done():
+			}
+		}
+		close(packages)
+		wg.Wait()
+		if firstErr != nil {
+			fatalf("error: %s\n", firstErr)
+		}
+		if err := it.Err(); err != nil {
+			fatalf("pagination error: %s\n", explain(err))
+		}
+
+		rows := make([]statsRow, 0, len(counts))
+		for row, count := range counts {
+			row.Downloads = count
+			rows = append(rows, row)
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Name != rows[j].Name {
+				return rows[i].Name < rows[j].Name
+			}
+			if c := pkgcloud.ComparePackageVersions(rows[i].pkg(), rows[j].pkg()); c != 0 {
+				return c < 0
+			}
+			return rows[i].Distro < rows[j].Distro
+		})
+		if err := writeStats(rows); err != nil {
+			fatalf("error: %s\n", err)
+		}
+	},
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+}
+
+// statsRow - the downloads of a package name and version in a distro
+type statsRow struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Distro    string `json:"distro"`
+	Downloads int    `json:"downloads"`
+
+	// The version, split up to compare it with pkgcloud.ComparePackageVersions
+	pkgType  string
+	epoch    int
+	upstream string
+	release  string
+}
+
+// pkg - a package with the version of row, to compare versions
+func (row statsRow) pkg() *pkgcloud.Package {
+	return &pkgcloud.Package{Type: row.pkgType, Epoch: row.epoch, Version: row.upstream, Release: row.release}
+}
+
+// newStatsRow - the row p is counted in
+func newStatsRow(p *pkgcloud.Package) statsRow {
+	version := p.Version
+	if p.Release != "" {
+		version += "-" + p.Release
+	}
+	return statsRow{
+		Name:     p.Name,
+		Version:  version,
+		Distro:   p.DistroVersion,
+		pkgType:  p.Type,
+		epoch:    p.Epoch,
+		upstream: p.Version,
+		release:  p.Release,
+	}
+}
+
+// statsDateRange - the pkgcloud.DateRange given by --start and --end
+func statsDateRange() (pkgcloud.DateRange, error) {
+	var dates pkgcloud.DateRange
+	var err error
+	if statsStart != "" {
+		if dates.Start, err = time.Parse("2006-01-02", statsStart); err != nil {
+			return dates, fmt.Errorf("invalid --start: %s", err)
+		}
+	}
+	if statsEnd != "" {
+		if dates.End, err = time.Parse("2006-01-02", statsEnd); err != nil {
+			return dates, fmt.Errorf("invalid --end: %s", err)
+		}
+	}
+	return dates, nil
+}
+
+// writeStats - write rows to stdout in the format given by --output
+func writeStats(rows []statsRow) error {
+	switch statsOutput {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "NAME\tVERSION\tDISTRO\tDOWNLOADS\n")
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", row.Name, row.Version, row.Distro, row.Downloads)
+		}
+		return w.Flush()
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"name", "version", "distro", "downloads"})
+		for _, row := range rows {
+			w.Write([]string{row.Name, row.Version, row.Distro, strconv.Itoa(row.Downloads)})
+		}
+		w.Flush()
+		return w.Error()
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return nil
+}
+
+var statsStart string
+
+var statsEnd string
+
+var statsName string
+
+var statsOutput string
+
+var statsJobs int
+
+func init() {
+	statsCmd.Flags().StringVar(&statsStart, "start", "", "Only count downloads from this date on (YYYY-MM-DD)")
+	statsCmd.Flags().StringVar(&statsEnd, "end", "", "Only count downloads up to this date (YYYY-MM-DD)")
+	statsCmd.Flags().StringVar(&statsName, "name", "", "Only count downloads of packages with this name")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", "table", "Output format: table, csv or json")
+	statsCmd.Flags().IntVarP(&statsJobs, "jobs", "j", 8, "Number of packages whose statistics are fetched concurrently")
+}
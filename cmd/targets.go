@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+)
+
+// pushTarget - a repo and distro packages are pushed to, as given on the command line
+type pushTarget struct {
+	// Repo - user/repo
+	Repo string
+	// Distro - distro/version, maybe with wildcards like ubuntu/* matched against the distributions
+	Distro string
+}
+
+// parsePushTargets - the targets of arg, user/repo/distro/version/, with alternatives like {xenial,bionic}
+// expanded
+func parsePushTargets(arg string) ([]pushTarget, error) {
+	var rv []pushTarget
+	for _, s := range expandBraces(arg) {
+		parts := strings.Split(strings.TrimSuffix(s, "/"), "/")
+		if len(parts) != 4 || parts[0] == "" || parts[1] == "" || parts[2] == "" || parts[3] == "" {
+			return nil, fmt.Errorf("%s is not of form user/repo/distro/version/", s)
+		}
+		if hasWildcards(parts[0]) || hasWildcards(parts[1]) {
+			return nil, fmt.Errorf("%s: only distros can have wildcards", s)
+		}
+		rv = append(rv, pushTarget{Repo: parts[0] + "/" + parts[1], Distro: parts[2] + "/" + parts[3]})
+	}
+	return rv, nil
+}
+
+// isPushTarget - whether arg, a command line argument of push, names targets rather than packages
+func isPushTarget(arg string) bool {
+	if arg == "-" || strings.Contains(arg, "://") || pkgcloud.PackageType(arg) != "" {
+		return false
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return false
+	}
+	if paths, err := globFiles(arg); err == nil && len(paths) > 0 {
+		return false
+	}
+	_, err := parsePushTargets(arg)
+	return err == nil
+}
+
+// distros - the distros packages of type pkgType are pushed to for t, matching its wildcards against the
+// distributions of resolver
+func (t pushTarget) distros(resolver *pkgcloud.DistroResolver, pkgType string) ([]string, error) {
+	if !hasWildcards(t.Distro) {
+		return []string{t.Distro}, nil
+	}
+	distros, err := resolver.Match(pkgType, t.Distro)
+	if err != nil {
+		return nil, err
+	}
+	if len(distros) == 0 {
+		if pkgType != "" {
+			return nil, fmt.Errorf("no distro for %s packages matches %s", pkgType, t.Distro)
+		}
+		return nil, fmt.Errorf("no distro matches %s", t.Distro)
+	}
+	return distros, nil
+}
+
+// hasWildcards - whether s has the wildcards of path.Match
+func hasWildcards(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// expandBraces - the strings s stands for, with the alternatives of the braces of s expanded like in shells:
+// a{b,c}d stands for abd and acd. Braces can be nested. Unbalanced braces are taken literally.
+func expandBraces(s string) []string {
+	open := strings.IndexByte(s, '{')
+	if open < 0 {
+		return []string{s}
+	}
+	depth := 0
+	start := open + 1
+	var alternatives []string
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, s[start:i])
+				start = i + 1
+			}
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			alternatives = append(alternatives, s[start:i])
+			var rv []string
+			suffixes := expandBraces(s[i+1:])
+			for _, alternative := range alternatives {
+				for _, middle := range expandBraces(alternative) {
+					for _, suffix := range suffixes {
+						rv = append(rv, s[:open]+middle+suffix)
+					}
+				}
+			}
+			return rv
+		}
+	}
+	// Unbalanced, the rest might still have braces
+	var rv []string
+	for _, rest := range expandBraces(s[open+1:]) {
+		rv = append(rv, s[:open+1]+rest)
+	}
+	return rv
+}
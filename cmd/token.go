@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"strconv"
+
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/spf13/cobra"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage the master and read tokens of a repo",
+	Long: `Manage the master and read tokens of a repo
+
+Master and read tokens can be referred to by id or by name.`,
+	TraverseChildren: true,
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list <user/repo>",
+	Short: "List the master tokens of a repo and their read tokens",
+	Long:  `List the master tokens of a repo and their read tokens`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		tokens, err := client.MasterTokensContext(rootContext, args[0])
+		if err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		out := newOutput(tokenListTemplateString, tokenJSON)
+		if tokenJSON {
+			out.write(tokens)
+			return
+		}
+		for _, token := range tokens {
+			out.write(token)
+		}
+	},
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+}
+
+var tokenCreateMasterCmd = &cobra.Command{
+	Use:   "create-master <user/repo> <name>",
+	Short: "Create a master token",
+	Long:  `Create a master token`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo, name := args[0], args[1]
+		if DryRun {
+			log.Printf("Dry Run for creating master token %s for %s", name, repo)
+			return
+		}
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		token, err := client.CreateMasterTokenContext(rootContext, repo, name)
+		if err != nil {
+			fatalf("error creating master token %s for %s: %s\n", name, repo, explain(err))
+		}
+		log.Printf("Created master token %s for %s", name, repo)
+		newOutput(tokenTemplateString, tokenJSON).write(token)
+	},
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+}
+
+var tokenDestroyMasterCmd = &cobra.Command{
+	Use:   "destroy-master <user/repo> <master-token>",
+	Short: "Destroy a master token and its read tokens",
+	Long:  `Destroy a master token and its read tokens`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := args[0]
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		master := findMasterToken(client, repo, args[1])
+		if DryRun {
+			log.Printf("Dry Run for destroying master token %s (%d) of %s and its %d read tokens", master.Name, master.ID, repo, len(master.ReadTokens))
+			return
+		}
+		if err := client.DestroyMasterTokenContext(rootContext, repo, master.ID); err != nil {
+			fatalf("error destroying master token %s of %s: %s\n", master.Name, repo, explain(err))
+		}
+		log.Printf("Destroyed master token %s (%d) of %s", master.Name, master.ID, repo)
+	},
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+}
+
+var tokenCreateReadCmd = &cobra.Command{
+	Use:   "create-read <user/repo> <master-token> <name>",
+	Short: "Create a read token from a master token",
+	Long: `Create a read token from a master token
+
+Outputs the value of the new read token.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo, name := args[0], args[2]
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		master := findMasterToken(client, repo, args[1])
+		if DryRun {
+			log.Printf("Dry Run for creating read token %s from master token %s of %s", name, master.Name, repo)
+			return
+		}
+		token, err := client.CreateReadTokenContext(rootContext, repo, master.ID, name)
+		if err != nil {
+			fatalf("error creating read token %s from master token %s of %s: %s\n", name, master.Name, repo, explain(err))
+		}
+		log.Printf("Created read token %s from master token %s of %s", name, master.Name, repo)
+		newOutput(tokenTemplateString, tokenJSON).write(token)
+	},
+	Args:             cobra.ExactArgs(3),
+	TraverseChildren: true,
+}
+
+var tokenDestroyReadCmd = &cobra.Command{
+	Use:   "destroy-read <user/repo> <master-token> <read-token>",
+	Short: "Destroy a read token",
+	Long:  `Destroy a read token`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := args[0]
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		master := findMasterToken(client, repo, args[1])
+		var token *pkgcloud.ReadToken
+		for _, t := range master.ReadTokens {
+			if tokenMatches(args[2], t.ID, t.Name) {
+				token = t
+				break
+			}
+		}
+		if token == nil {
+			fatalf("no read token %s in master token %s of %s", args[2], master.Name, repo)
+		}
+		if DryRun {
+			log.Printf("Dry Run for destroying read token %s (%d) of %s", token.Name, token.ID, repo)
+			return
+		}
+		if err := client.DestroyReadTokenContext(rootContext, repo, master.ID, token.ID); err != nil {
+			fatalf("error destroying read token %s of %s: %s\n", token.Name, repo, explain(err))
+		}
+		log.Printf("Destroyed read token %s (%d) of %s", token.Name, token.ID, repo)
+	},
+	Args:             cobra.ExactArgs(3),
+	TraverseChildren: true,
+}
+
+// findMasterToken - the master token of repo with the id or name ref, exits if there is none
+func findMasterToken(client *pkgcloud.Client, repo, ref string) *pkgcloud.MasterToken {
+	tokens, err := client.MasterTokensContext(rootContext, repo)
+	if err != nil {
+		fatalf("error: %s\n", explain(err))
+	}
+	for _, token := range tokens {
+		if tokenMatches(ref, token.ID, token.Name) {
+			return token
+		}
+	}
+	fatalf("no master token %s in %s", ref, repo)
+	return nil
+}
+
+// tokenMatches - whether ref is the id or the name of a token
+func tokenMatches(ref string, id int, name string) bool {
+	return ref == strconv.Itoa(id) || ref == name
+}
+
+var tokenJSON bool
+
+var tokenListTemplateString string
+
+var tokenTemplateString string
+
+func init() {
+	tokenCmd.PersistentFlags().BoolVar(&tokenJSON, "json", false, "Output JSON instead of using the template")
+	tokenListCmd.Flags().StringVarP(&tokenListTemplateString, "template", "t", tokenListTemplate, "Golang text template for output")
+	for _, cmd := range []*cobra.Command{tokenCreateMasterCmd, tokenCreateReadCmd} {
+		cmd.Flags().StringVarP(&tokenTemplateString, "template", "t", "{{.Value}}\n", "Golang text template for output")
+	}
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenCreateMasterCmd)
+	tokenCmd.AddCommand(tokenDestroyMasterCmd)
+	tokenCmd.AddCommand(tokenCreateReadCmd)
+	tokenCmd.AddCommand(tokenDestroyReadCmd)
+}
+
+const tokenListTemplate = `{{.ID}} {{.Name}}: {{.Value}}
+{{range .ReadTokens}}    {{.ID}} {{.Name}}: {{.Value}}
+{{end}}`
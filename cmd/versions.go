@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	pkgcloud "github.com/edwarnicke/pkgcloud/pkgcloudlib"
+	"github.com/spf13/cobra"
+)
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions <user/repo> <distro/version> <package> <arch>",
+	Short: "List the versions of a package",
+	Long: `List the versions of a package for a distro version and architecture, from the oldest to the newest
+
+The package type of the distro version is looked up in the distributions, use
+--type to choose dsc over deb. The versions are output with a template, just
+like 'pkgcloud all' does, including the Promote and Destroy methods.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fatalf("error: %s\n", err)
+		}
+		pkgType := distroType(client, args[1], versionsType)
+		packages, err := client.PackageVersionsContext(rootContext, args[0], pkgType, args[1], args[2], args[3])
+		if err != nil {
+			fatalf("error: %s\n", explain(err))
+		}
+		if versionsLatest && len(packages) > 0 {
+			packages = packages[len(packages)-1:]
+		}
+		listPackages(client, &packageSlice{packages: packages}, versionsTemplateString)
+	},
+	Args:             cobra.ExactArgs(4),
+	TraverseChildren: true,
+}
+
+// distroType - the package type of distro: pkgType if set, the one from the distributions otherwise
+func distroType(client *pkgcloud.Client, distro, pkgType string) string {
+	if pkgType != "" {
+		return pkgType
+	}
+	pkgType, err := client.DistroTypeContext(rootContext, distro)
+	if err != nil {
+		fatalf("error: %s\n", explain(err))
+	}
+	return pkgType
+}
+
+// packageSlice - lists packages, like pkgcloud.PackageIterator does
+type packageSlice struct {
+	packages []*pkgcloud.Package
+	current  *pkgcloud.Package
+}
+
+func (s *packageSlice) Next() bool {
+	if len(s.packages) == 0 {
+		return false
+	}
+	s.current, s.packages = s.packages[0], s.packages[1:]
+	return true
+}
+
+func (s *packageSlice) Package() *pkgcloud.Package {
+	return s.current
+}
+
+func (s *packageSlice) Err() error {
+	return nil
+}
+
+var versionsTemplateString string
+
+var versionsType string
+
+var versionsLatest bool
+
+func init() {
+	versionsCmd.Flags().StringVarP(&versionsTemplateString, "template", "t", "{{if .Epoch}}{{.Epoch}}:{{end}}{{.Version}}{{with .Release}}-{{.}}{{end}} {{.Filename}}\n", "Golang text template for output")
+	versionsCmd.Flags().StringVar(&versionsType, "type", "", "Package type of the distro/version: deb, dsc or rpm (default from the distributions)")
+	versionsCmd.Flags().BoolVar(&versionsLatest, "latest", false, "Only list the newest version")
+}
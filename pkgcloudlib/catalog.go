@@ -0,0 +1,182 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL - how long the cached distributions are used before being fetched again
+const DefaultCacheTTL = 24 * time.Hour
+
+// catalog - the distributions, once a Client has loaded them
+type catalog struct {
+	mu            sync.Mutex
+	distributions *Distributions
+}
+
+// defaultCacheDir - the directory of the cache of a Client, empty if there's no user cache dir
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pkgcloud")
+}
+
+// CachedDistributions - the distributions of packagecloud.io, without fetching them every time:
+// they are kept in memory by the client, and on disk for the TTL of the client's cache.
+// When they can't be fetched, the distributions cached on disk are used however old. Without a
+// cache, fetching them is required: distros.go, the snapshot built into the library for
+// packagecloud.io, is empty unless regenerated with go generate.
+func (c *Client) CachedDistributions() (*Distributions, error) {
+	return c.CachedDistributionsContext(context.Background())
+}
+
+// CachedDistributionsContext is like CachedDistributions, but with a context.
+func (c *Client) CachedDistributionsContext(ctx context.Context) (*Distributions, error) {
+	if c.catalog != nil {
+		c.catalog.mu.Lock()
+		defer c.catalog.mu.Unlock()
+		if c.catalog.distributions != nil {
+			return c.catalog.distributions, nil
+		}
+	}
+	d, modTime, cacheErr := c.readDistributionsCache()
+	if cacheErr == nil && time.Since(modTime) < c.cacheTTL {
+		c.keepDistributions(d)
+		return d, nil
+	}
+	fetched, err := c.fetchDistributions(ctx)
+	switch {
+	case err == nil:
+		d = fetched
+	case ctx.Err() != nil:
+		return nil, err
+	case cacheErr == nil:
+		// Stale, but better than nothing
+	case c.baseURL()+"/" == ServiceBaseURL && builtinDistributions() != nil:
+		d = builtinDistributions()
+	default:
+		return nil, err
+	}
+	c.keepDistributions(d)
+	return d, nil
+}
+
+// RefreshDistributions - fetches the distributions of packagecloud.io, and updates the cache with them
+func (c *Client) RefreshDistributions() (*Distributions, error) {
+	return c.RefreshDistributionsContext(context.Background())
+}
+
+// RefreshDistributionsContext is like RefreshDistributions, but with a context.
+func (c *Client) RefreshDistributionsContext(ctx context.Context) (*Distributions, error) {
+	d, err := c.fetchDistributions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.catalog != nil {
+		c.catalog.mu.Lock()
+		defer c.catalog.mu.Unlock()
+		c.keepDistributions(d)
+	}
+	return d, nil
+}
+
+// keepDistributions - keeps d in memory. c.catalog.mu must be held.
+func (c *Client) keepDistributions(d *Distributions) {
+	if c.catalog != nil {
+		c.catalog.distributions = d
+	}
+}
+
+// fetchDistributions - fetches the distributions, and writes them to the cache
+func (c *Client) fetchDistributions(ctx context.Context) (*Distributions, error) {
+	d, err := c.DistributionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only an optimization, failing to write it is no reason to fail
+	_ = c.writeDistributionsCache(d)
+	return d, nil
+}
+
+// distributionsCachePath - the file caching the distributions of the packagecloud instance of c,
+// empty when there's no cache
+func (c *Client) distributionsCachePath() string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	host := "default"
+	if u, err := url.Parse(c.baseURL()); err == nil && u.Host != "" {
+		host = strings.Replace(u.Host, ":", "_", -1)
+	}
+	return filepath.Join(c.cacheDir, "distributions-"+host+".json")
+}
+
+// readDistributionsCache - the cached distributions, and when they were cached
+func (c *Client) readDistributionsCache() (*Distributions, time.Time, error) {
+	path := c.distributionsCachePath()
+	if path == "" {
+		return nil, time.Time{}, os.ErrNotExist
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	d := &Distributions{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, time.Time{}, err
+	}
+	return d, info.ModTime(), nil
+}
+
+// writeDistributionsCache - caches d, atomically so that concurrent readers never see a partial file
+func (c *Client) writeDistributionsCache(d *Distributions) error {
+	path := c.distributionsCachePath()
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".distributions-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// builtinDistributions - the snapshot of the distributions of packagecloud.io in distros.go, nil if it's empty
+func builtinDistributions() *Distributions {
+	if builtinDistributionsJSON == "" {
+		return nil
+	}
+	d := &Distributions{}
+	if err := json.Unmarshal([]byte(builtinDistributionsJSON), d); err != nil {
+		return nil
+	}
+	return d
+}
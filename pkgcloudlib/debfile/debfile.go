@@ -0,0 +1,303 @@
+// Package debfile reads the metadata of Debian binary packages (.deb files),
+// without any external tool.
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html and deb(5)
+package debfile
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	arMagic        = "!<arch>\n"
+	arHeaderLength = 60
+	// maxControlSize - bounds the size of the control archive read into memory
+	maxControlSize = 64 << 20
+)
+
+// Field - a field of the control file of a package
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Package - the metadata of a .deb
+type Package struct {
+	// Package - the name of the package
+	Package string `json:"package"`
+	// Version - the version of the package
+	Version Version `json:"version"`
+	// Architecture - the architecture of the package, like amd64 or all
+	Architecture string `json:"architecture"`
+	// Depends - the dependencies of the package, like "libc6 (>= 2.14)" or "foo | bar"
+	Depends []string `json:"depends,omitempty"`
+	// Fields - all the fields of the control file, in order
+	Fields []Field `json:"fields"`
+}
+
+// Field - the value of the control field name, compared case insensitively. Empty if there's none.
+func (p *Package) Field(name string) string {
+	for _, f := range p.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Relations - the package relations of the control field name, like Depends or Pre-Depends
+func (p *Package) Relations(name string) []string {
+	return splitRelations(p.Field(name))
+}
+
+// Filename - the canonical filename of the package, name_version_architecture.deb,
+// with the version without epoch, as packagecloud.io and Debian repositories name it
+func (p *Package) Filename() string {
+	v := p.Version
+	v.Epoch = 0
+	return fmt.Sprintf("%s_%s_%s.deb", p.Package, v, p.Architecture)
+}
+
+// Version - a Debian package version, [epoch:]upstream[-revision]
+type Version struct {
+	Epoch    int
+	Upstream string
+	Revision string
+}
+
+// ParseVersion - the Version s
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, ":"); i >= 0 {
+		epoch, err := strconv.Atoi(s[:i])
+		if err != nil || epoch < 0 {
+			return v, fmt.Errorf("debfile: invalid epoch in version %q", s)
+		}
+		v.Epoch, s = epoch, s[i+1:]
+	}
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		v.Revision, s = s[i+1:], s[:i]
+	}
+	if s == "" {
+		return v, fmt.Errorf("debfile: empty upstream version")
+	}
+	v.Upstream = s
+	return v, nil
+}
+
+// String - the version as written in control files
+func (v Version) String() string {
+	s := v.Upstream
+	if v.Epoch != 0 {
+		s = strconv.Itoa(v.Epoch) + ":" + s
+	}
+	if v.Revision != "" {
+		s += "-" + v.Revision
+	}
+	return s
+}
+
+// MarshalText - the version as written in control files
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText - parses the version text
+func (v *Version) UnmarshalText(text []byte) error {
+	parsed, err := ParseVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Open - the metadata of the .deb at path
+func Open(path string) (*Package, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return p, nil
+}
+
+// Read - the metadata of the .deb read from r. Only the beginning of the .deb, up to the
+// control archive, is read.
+func Read(r io.Reader) (*Package, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != arMagic {
+		return nil, errors.New("debfile: not a .deb: no ar archive")
+	}
+	for {
+		name, size, err := readARHeader(br)
+		if err == io.EOF {
+			return nil, errors.New("debfile: not a .deb: no control archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		member := io.LimitReader(br, size)
+		if strings.HasPrefix(name, "control.tar") {
+			if size > maxControlSize {
+				return nil, fmt.Errorf("debfile: control archive too large: %d bytes", size)
+			}
+			return readControlArchive(name, member)
+		}
+		if _, err := io.Copy(io.Discard, member); err != nil {
+			return nil, err
+		}
+		if size%2 == 1 {
+			// Members are aligned on even offsets
+			if _, err := br.ReadByte(); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readARHeader - the name and size of the next member of the ar archive r
+func readARHeader(r io.Reader) (string, int64, error) {
+	header := make([]byte, arHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, errors.New("debfile: truncated ar archive")
+		}
+		return "", 0, err
+	}
+	if string(header[58:60]) != "`\n" {
+		return "", 0, errors.New("debfile: invalid ar member header")
+	}
+	// GNU ar terminates names with a slash
+	name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+	size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, fmt.Errorf("debfile: invalid size of ar member %s", name)
+	}
+	return name, size, nil
+}
+
+// readControlArchive - the package described by the control file of the control archive r, named name
+func readControlArchive(name string, r io.Reader) (*Package, error) {
+	var tr *tar.Reader
+	switch path.Ext(name) {
+	case ".tar":
+		tr = tar.NewReader(r)
+	case ".gz":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("debfile: %s: %s", name, err)
+		}
+		defer zr.Close()
+		tr = tar.NewReader(zr)
+	case ".xz":
+		zr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("debfile: %s: %s", name, err)
+		}
+		tr = tar.NewReader(zr)
+	case ".zst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("debfile: %s: %s", name, err)
+		}
+		defer zr.Close()
+		tr = tar.NewReader(zr)
+	default:
+		return nil, fmt.Errorf("debfile: unsupported control archive %s", name)
+	}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("debfile: no control file in %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("debfile: %s: %s", name, err)
+		}
+		if path.Clean(strings.TrimPrefix(h.Name, "./")) != "control" {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxControlSize))
+		if err != nil {
+			return nil, fmt.Errorf("debfile: %s: %s", name, err)
+		}
+		return ParseControl(data)
+	}
+}
+
+// ParseControl - the package described by the control file data
+func ParseControl(data []byte) (*Package, error) {
+	p := &Package{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxControlSize)
+lines:
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			// A control file of a binary package has a single paragraph
+			if len(p.Fields) > 0 {
+				break lines
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if len(p.Fields) == 0 {
+				return nil, fmt.Errorf("debfile: continuation line before any field: %q", line)
+			}
+			f := &p.Fields[len(p.Fields)-1]
+			f.Value += "\n" + line[1:]
+		case line[0] == '#':
+			// Comment
+		default:
+			i := strings.Index(line, ":")
+			if i <= 0 {
+				return nil, fmt.Errorf("debfile: invalid control line: %q", line)
+			}
+			p.Fields = append(p.Fields, Field{Name: line[:i], Value: strings.TrimSpace(line[i+1:])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.Package = p.Field("Package")
+	p.Architecture = p.Field("Architecture")
+	p.Depends = p.Relations("Depends")
+	if p.Package == "" {
+		return nil, errors.New("debfile: no Package field in control file")
+	}
+	version, err := ParseVersion(p.Field("Version"))
+	if err != nil {
+		return nil, err
+	}
+	p.Version = version
+	return p, nil
+}
+
+// splitRelations - the comma separated package relations in value
+func splitRelations(value string) []string {
+	var rv []string
+	for _, rel := range strings.Split(value, ",") {
+		rel = strings.Join(strings.Fields(rel), " ")
+		if rel != "" {
+			rv = append(rv, rel)
+		}
+	}
+	return rv
+}
@@ -0,0 +1,110 @@
+package debfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	want := &Package{
+		Package:      "hello",
+		Version:      Version{Epoch: 2, Upstream: "1.2.3", Revision: "4ubuntu1"},
+		Architecture: "amd64",
+		Depends:      []string{"libc6 (>= 2.14)", "foo | bar"},
+	}
+	tests := []struct {
+		file string
+		err  string
+	}{
+		{file: "testdata/hello-gz.deb"},
+		{file: "testdata/hello-xz.deb"},
+		{file: "testdata/hello-zst.deb"},
+		{file: "testdata/truncated.deb", err: "control.tar.gz"},
+		{file: "testdata/missing.deb", err: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			p, err := Open(tt.file)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Open(%s) error = %v, want one containing %q", tt.file, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open(%s): %s", tt.file, err)
+			}
+			if p.Package != want.Package || p.Version != want.Version || p.Architecture != want.Architecture ||
+				!reflect.DeepEqual(p.Depends, want.Depends) {
+				t.Errorf("Open(%s) = %+v, want %+v", tt.file, p, want)
+			}
+			if got := p.Field("description"); got != "a test package\nmulti-line description" {
+				t.Errorf("Field(description) = %q", got)
+			}
+			if got := p.Filename(); got != "hello_1.2.3-4ubuntu1_amd64.deb" {
+				t.Errorf("Filename() = %q", got)
+			}
+		})
+	}
+}
+
+func TestReadNotADeb(t *testing.T) {
+	if _, err := Read(strings.NewReader("#!/bin/sh\necho not a deb\n")); err == nil {
+		t.Error("Read of a shell script succeeded")
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		err  bool
+	}{
+		{in: "1.0", want: Version{Upstream: "1.0"}},
+		{in: "1.0-1", want: Version{Upstream: "1.0", Revision: "1"}},
+		{in: "1:2.3-4-5", want: Version{Epoch: 1, Upstream: "2.3-4", Revision: "5"}},
+		{in: "18.10~rc0", want: Version{Upstream: "18.10~rc0"}},
+		{in: "x:1.0", err: true},
+		{in: "1:", err: true},
+		{in: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseVersion(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if !tt.err && got.String() != tt.in {
+			t.Errorf("ParseVersion(%q).String() = %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestParseControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		control string
+		err     bool
+	}{
+		{name: "second paragraph ignored", control: "Package: a\nVersion: 1\n\nPackage: b\n"},
+		{name: "comment", control: "# comment\nPackage: a\nVersion: 1\n"},
+		{name: "no package", control: "Version: 1\n", err: true},
+		{name: "continuation first", control: " Package: a\n", err: true},
+		{name: "no colon", control: "Package a\n", err: true},
+		{name: "bad version", control: "Package: a\nVersion: x:1\n", err: true},
+	}
+	for _, tt := range tests {
+		p, err := ParseControl([]byte(tt.control))
+		if (err != nil) != tt.err {
+			t.Errorf("%s: ParseControl error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if !tt.err && p.Package != "a" {
+			t.Errorf("%s: Package = %q, want a", tt.name, p.Package)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// PackageDetails - packagecloud.io PackageDetails structure, the full description of a package
+// See for detailed description of fields: https://packagecloud.io/docs/api#object_PackageDetails
+type PackageDetails struct {
+	Name               string              `json:"name"`
+	DistroVersion      string              `json:"distro_version"`
+	Architecture       string              `json:"architecture"`
+	Repository         string              `json:"repository"`
+	Size               int64               `json:"size"`
+	Summary            string              `json:"summary"`
+	Filename           string              `json:"filename"`
+	Description        string              `json:"description"`
+	Dependencies       PackageDependencies `json:"dependencies"`
+	Md5                string              `json:"md5sum"`
+	Sha1               string              `json:"sha1sum"`
+	Sha256             string              `json:"sha256sum"`
+	Sha512             string              `json:"sha512sum"`
+	Private            bool                `json:"private"`
+	UploaderName       string              `json:"uploader_name"`
+	CreatedAt          time.Time           `json:"created_at"`
+	Licenses           []string            `json:"licenses"`
+	Version            string              `json:"version"`
+	Release            string              `json:"release"`
+	Epoch              int                 `json:"epoch"`
+	Indexed            bool                `json:"indexed"`
+	Scope              string              `json:"scope"`
+	Type               string              `json:"type"`
+	RepositoryHTMLURL  string              `json:"repository_html_url"`
+	PackageHTMLURL     string              `json:"package_html_url"`
+	DownloadURL        string              `json:"download_url"`
+	PromoteURL         string              `json:"promote_url"`
+	DestroyURL         string              `json:"destroy_url"`
+	SelfURL            string              `json:"self_url"`
+	DownloadDetailsURL string              `json:"downloads_detail_url"`
+	DownloadSeriesURL  string              `json:"downloads_series_url"`
+	DownloadCountURL   string              `json:"downloads_count_url"`
+	Files              []PackageFile       `json:"files"`
+}
+
+// PackageFile - a file making up a package, e.g. the .dsc, .orig.tar.gz and .debian.tar.xz of a source package
+type PackageFile struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Md5      string `json:"md5sum"`
+	Sha1     string `json:"sha1sum"`
+	Sha256   string `json:"sha256sum"`
+}
+
+// PackageDependencies - dependency lists of a package, by kind of dependency (e.g. "depends", "requires")
+type PackageDependencies map[string][]string
+
+// UnmarshalJSON accepts either lists by kind, or a single list stored as "depends".
+func (d *PackageDependencies) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*d = nil
+		if len(list) > 0 {
+			*d = PackageDependencies{"depends": list}
+		}
+		return nil
+	}
+	var byKind map[string][]string
+	if err := json.Unmarshal(b, &byKind); err != nil {
+		return err
+	}
+	*d = byKind
+	return nil
+}
+
+// PackageDetails - retrieve the full description of p
+func (c *Client) PackageDetails(p *Package) (*PackageDetails, error) {
+	return c.PackageDetailsContext(context.Background(), p)
+}
+
+// PackageDetailsContext is like PackageDetails, but with a context.
+func (c *Client) PackageDetailsContext(ctx context.Context, p *Package) (*PackageDetails, error) {
+	req, err := c.newRequest(ctx, "GET", c.resolveURL(p.PackageURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	details := &PackageDetails{}
+	err = c.do(req, details)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
@@ -0,0 +1,7 @@
+// Code generated by gendistros; DO NOT EDIT.
+
+package pkgcloudlib
+
+// builtinDistributionsJSON - snapshot of the distributions of packagecloud.io, see CachedDistributions.
+// This one is empty: regenerate it with go generate and an API token to have one.
+const builtinDistributionsJSON = ""
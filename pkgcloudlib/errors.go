@@ -0,0 +1,127 @@
+package pkgcloudlib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Errors an *APIError can be matched against with errors.Is.
+var (
+	// ErrNotFound - the resource does not exist (404)
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized - the API token is missing or invalid (401)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden - the API token doesn't grant access to the resource (403)
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict - the resource already exists (409, or a 422 for a taken name)
+	ErrConflict = errors.New("conflict")
+	// ErrRateLimited - too many requests were sent (429)
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError describes an unsuccessful response of the packagecloud API.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	// RequestID is the X-Request-Id of the response, if any
+	RequestID string
+	// Message is the error message of the response body, if any
+	Message string
+	// Errors are the validation errors of the response body, by field
+	Errors map[string][]string
+}
+
+// newAPIError builds an *APIError out of resp, consuming its body.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Endpoint = resp.Request.URL.String()
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return e
+	}
+	for field, v := range body {
+		switch v := v.(type) {
+		case string:
+			if field == "error" || field == "message" {
+				e.Message = v
+			}
+		case []interface{}:
+			for _, msg := range v {
+				if msg, ok := msg.(string); ok {
+					if e.Errors == nil {
+						e.Errors = make(map[string][]string)
+					}
+					e.Errors[field] = append(e.Errors[field], msg)
+				}
+			}
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	var details []string
+	if e.Message != "" {
+		details = append(details, e.Message)
+	}
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for _, m := range e.Errors[field] {
+			details = append(details, field+" "+m)
+		}
+	}
+	if len(details) > 0 {
+		msg += ": " + strings.Join(details, ", ")
+	}
+	if e.RequestID != "" {
+		msg += " (request id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is reports whether e matches one of the ErrNotFound, ErrUnauthorized,
+// ErrForbidden, ErrConflict and ErrRateLimited sentinels.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		if e.StatusCode == http.StatusConflict {
+			return true
+		}
+		if e.StatusCode != http.StatusUnprocessableEntity {
+			return false
+		}
+		for _, messages := range e.Errors {
+			for _, m := range messages {
+				if strings.Contains(m, "already been taken") {
+					return true
+				}
+			}
+		}
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FindPackage - the package of repo named filename in distro, like "ubuntu/xenial".
+// deb, dsc and rpm packages named like packagecloud.io names them are looked up in the versions
+// of the package, for the name and architecture in filename. Other packages are searched for by
+// the name filename starts with.
+// Returns an error matching ErrNotFound with errors.Is when there's no such package.
+func (c *Client) FindPackage(repo, distro, filename string) (*Package, error) {
+	return c.FindPackageContext(context.Background(), repo, distro, filename)
+}
+
+// FindPackageContext is like FindPackage, but with a context.
+func (c *Client) FindPackageContext(ctx context.Context, repo, distro, filename string) (*Package, error) {
+	pkgType, name, arch := packageCoordinates(filename)
+	var p *Package
+	var err error
+	if name != "" {
+		p, err = c.findPackageVersion(ctx, repo, pkgType, distro, name, arch, filename)
+	} else {
+		p, err = c.searchPackage(ctx, repo, pkgType, distro, filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("package %s in %s/%s: %w", filename, repo, distro, ErrNotFound)
+	}
+	return p, nil
+}
+
+// findPackageVersion - the package named filename among the versions of the package name for arch,
+// nil if there's none
+func (c *Client) findPackageVersion(ctx context.Context, repo, pkgType, distro, name, arch, filename string) (*Package, error) {
+	versions, err := c.PackageVersionsContext(ctx, repo, pkgType, distro, name, arch)
+	if errors.Is(err, ErrNotFound) {
+		// No such package, or no such repo
+		if _, repoErr := c.RepositoryContext(ctx, repo); repoErr != nil {
+			return nil, repoErr
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range versions {
+		if p.Filename == filename {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// searchPackage - the package named filename in distro among the ones found searching for the name
+// filename starts with, nil if there's none
+func (c *Client) searchPackage(ctx context.Context, repo, pkgType, distro, filename string) (*Package, error) {
+	q := SearchQuery{Query: packageName(filename), Dist: distro}
+	if searchFilters[pkgType] != "" {
+		q.Type = pkgType
+	}
+	it := c.SearchContext(ctx, repo, q, &ListOptions{PerPage: PerPageMax})
+	defer it.Close()
+	for it.Next() {
+		if p := it.Package(); p.Filename == filename && p.DistroVersion == distro {
+			return p, nil
+		}
+	}
+	return nil, it.Err()
+}
+
+// LookupPackage - the package of repo named filename in distro, like "ubuntu/xenial", and whether there's one.
+// Unlike with FindPackage, a missing package isn't an error: nil, false, nil is returned. A missing repo is.
+func (c *Client) LookupPackage(repo, distro, filename string) (*Package, bool, error) {
+	return c.LookupPackageContext(context.Background(), repo, distro, filename)
+}
+
+// LookupPackageContext is like LookupPackage, but with a context.
+func (c *Client) LookupPackageContext(ctx context.Context, repo, distro, filename string) (*Package, bool, error) {
+	p, err := c.FindPackageContext(ctx, repo, distro, filename)
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return p, true, nil
+	case errors.Is(err, ErrNotFound) && !errors.As(err, &apiErr):
+		// Not among the packages found, rather than a 404 of the repo
+		return nil, false, nil
+	}
+	return nil, false, err
+}
+
+// packageCoordinates - the package type, package name and architecture of the package file filename,
+// as packagecloud.io names deb, dsc and rpm files: name_version_arch.deb, name_version.dsc and
+// name-version-release.arch.rpm. The name and architecture are empty when filename isn't named so.
+func packageCoordinates(filename string) (pkgType, name, arch string) {
+	pkgType = PackageType(filename)
+	base := path.Base(filename)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	switch pkgType {
+	case "deb":
+		if parts := strings.Split(base, "_"); len(parts) == 3 && parts[0] != "" && parts[2] != "" {
+			name, arch = parts[0], parts[2]
+		}
+	case "dsc":
+		if parts := strings.Split(base, "_"); len(parts) == 2 && parts[0] != "" {
+			name, arch = parts[0], "source"
+		}
+	case "rpm":
+		ext := path.Ext(base)
+		nvr := strings.TrimSuffix(base, ext)
+		i := strings.LastIndex(nvr, "-")
+		if i <= 0 || len(ext) < 2 {
+			break
+		}
+		if j := strings.LastIndex(nvr[:i], "-"); j > 0 {
+			name, arch = nvr[:j], ext[1:]
+		}
+	}
+	return pkgType, name, arch
+}
+
+// packageName - the name of the package the package file filename is likely of, like "vpp" for
+// vpp_18.07_amd64.deb or vpp-18.07-release.x86_64.rpm
+func packageName(filename string) string {
+	base := path.Base(filename)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	switch PackageType(filename) {
+	case "deb", "dsc":
+		// name_version_arch.deb, name_version.dsc
+		name = strings.SplitN(name, "_", 2)[0]
+	case "rpm":
+		// name-version-release.arch.rpm
+		name = strings.TrimSuffix(name, path.Ext(name))
+		for i := 0; i < 2; i++ {
+			if j := strings.LastIndex(name, "-"); j > 0 {
+				name = name[:j]
+			}
+		}
+	case "gem":
+		// name-version.gem
+		if j := strings.LastIndex(name, "-"); j > 0 {
+			name = name[:j]
+		}
+	case "python":
+		// name-version-....whl
+		name = strings.SplitN(name, "-", 2)[0]
+	}
+	return name
+}
+
+// FileSha256 - the sha256 sum of the file of the package named filename: that of the package itself,
+// or that of one of its files, e.g. the .dsc of a source package. Empty when it isn't known.
+func (d *PackageDetails) FileSha256(filename string) string {
+	for _, f := range d.Files {
+		if f.Filename == filename && f.Sha256 != "" {
+			return f.Sha256
+		}
+	}
+	if d.Filename == "" || d.Filename == filename {
+		return d.Sha256
+	}
+	return ""
+}
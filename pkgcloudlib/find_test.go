@@ -0,0 +1,179 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const versionsPath = "/api/v1/repos/a/b/package/deb/ubuntu/xenial/foo/amd64/versions.json"
+
+func TestLookupPackage(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		// versions - the status and body of the response to the versions of foo for amd64
+		versions int
+		body     string
+		// repo - the status of the response to the repository
+		repo int
+		// search - the status and body of the response to the search
+		search     int
+		searchBody string
+		exists     bool
+		err        error
+	}{
+		{
+			name:     "found",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusOK,
+			body:     `[{"filename":"foo_1.0-2_amd64.deb"},{"filename":"foo_1.0-1_amd64.deb","distro_version":"ubuntu/xenial"}]`,
+			exists:   true,
+		},
+		{
+			name:     "other versions",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusOK,
+			body:     `[{"filename":"foo_1.0-2_amd64.deb"}]`,
+		},
+		{
+			name:     "no such package",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusNotFound,
+			repo:     http.StatusOK,
+		},
+		{
+			name:     "no such repo",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusNotFound,
+			repo:     http.StatusNotFound,
+			err:      ErrNotFound,
+		},
+		{
+			name:     "invalid token",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusUnauthorized,
+			err:      ErrUnauthorized,
+		},
+		{
+			name:     "no access to the repo",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusForbidden,
+			err:      ErrForbidden,
+		},
+		{
+			name:     "server error",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusInternalServerError,
+			err:      &APIError{StatusCode: http.StatusInternalServerError},
+		},
+		{
+			name:     "server error checking the repo",
+			filename: "foo_1.0-1_amd64.deb",
+			versions: http.StatusNotFound,
+			repo:     http.StatusBadGateway,
+			err:      &APIError{StatusCode: http.StatusBadGateway},
+		},
+		{
+			name:       "not named like packagecloud.io names packages",
+			filename:   "foo.deb",
+			search:     http.StatusOK,
+			searchBody: `[{"filename":"foo_1.0-1_amd64.deb","distro_version":"ubuntu/xenial"},{"filename":"foo.deb","distro_version":"ubuntu/xenial"}]`,
+			exists:     true,
+		},
+		{
+			name:       "not named like packagecloud.io names packages, in another distro",
+			filename:   "foo.deb",
+			search:     http.StatusOK,
+			searchBody: `[{"filename":"foo.deb","distro_version":"ubuntu/bionic"}]`,
+		},
+		{
+			name:     "not named like packagecloud.io names packages, invalid token",
+			filename: "foo.deb",
+			search:   http.StatusUnauthorized,
+			err:      ErrUnauthorized,
+		},
+		{
+			name:     "not named like packagecloud.io names packages, server error",
+			filename: "foo.deb",
+			search:   http.StatusServiceUnavailable,
+			err:      &APIError{StatusCode: http.StatusServiceUnavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case versionsPath:
+					w.WriteHeader(tt.versions)
+					w.Write([]byte(tt.body))
+				case "/api/v1/repos/a/b.json":
+					w.WriteHeader(tt.repo)
+					w.Write([]byte(`{}`))
+				case "/api/v1/repos/a/b/search.json":
+					if q := r.URL.Query(); q.Get("q") != "foo" || q.Get("filter") != "debs" || q.Get("dist") != "ubuntu" {
+						t.Errorf("unexpected search %s", r.URL.RawQuery)
+					}
+					w.WriteHeader(tt.search)
+					w.Write([]byte(tt.searchBody))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusTeapot)
+				}
+			}))
+			defer srv.Close()
+			client, err := New(WithToken("token"), WithURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, exists, err := client.LookupPackageContext(context.Background(), "a/b", "ubuntu/xenial", tt.filename)
+			if tt.err == nil && err != nil {
+				t.Fatalf("LookupPackage: %s", err)
+			}
+			if tt.err != nil {
+				var apiErr *APIError
+				want, wantAPIErr := tt.err.(*APIError)
+				switch {
+				case err == nil:
+					t.Fatalf("LookupPackage: no error, want %v", tt.err)
+				case wantAPIErr && (!errors.As(err, &apiErr) || apiErr.StatusCode != want.StatusCode):
+					t.Fatalf("LookupPackage error = %v, want a %d", err, want.StatusCode)
+				case !wantAPIErr && !errors.Is(err, tt.err):
+					t.Fatalf("LookupPackage error = %v, want %v", err, tt.err)
+				}
+			}
+			if exists != tt.exists || (p != nil) != tt.exists {
+				t.Fatalf("LookupPackage = %v, %v, want exists %v", p, exists, tt.exists)
+			}
+			if exists && p.Filename != tt.filename {
+				t.Errorf("LookupPackage = %s, want %s", p.Filename, tt.filename)
+			}
+		})
+	}
+}
+
+func TestPackageCoordinates(t *testing.T) {
+	tests := []struct {
+		filename            string
+		pkgType, name, arch string
+	}{
+		{filename: "vpp_18.07-rc0~1_amd64.deb", pkgType: "deb", name: "vpp", arch: "amd64"},
+		{filename: "dir/vpp-dbg_18.07_arm64.ddeb", pkgType: "deb", name: "vpp-dbg", arch: "arm64"},
+		{filename: "vpp_18.07.dsc", pkgType: "dsc", name: "vpp", arch: "source"},
+		{filename: "vpp-lib-18.07-release.x86_64.rpm", pkgType: "rpm", name: "vpp-lib", arch: "x86_64"},
+		{filename: "vpp-18.07-1.el7.src.rpm", pkgType: "rpm", name: "vpp", arch: "src"},
+		{filename: "vpp.deb", pkgType: "deb"},
+		{filename: "vpp_18.07.deb", pkgType: "deb"},
+		{filename: "vpp-18.07.x86_64.rpm", pkgType: "rpm"},
+		{filename: "vpp.rpm", pkgType: "rpm"},
+		{filename: "vpp-18.07.gem", pkgType: "gem"},
+	}
+	for _, tt := range tests {
+		pkgType, name, arch := packageCoordinates(tt.filename)
+		if pkgType != tt.pkgType || name != tt.name || arch != tt.arch {
+			t.Errorf("packageCoordinates(%s) = %q, %q, %q, want %q, %q, %q", tt.filename, pkgType, name, arch, tt.pkgType, tt.name, tt.arch)
+		}
+	}
+}
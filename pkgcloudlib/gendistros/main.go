@@ -0,0 +1,128 @@
+// gendistros writes distros.go, the snapshot of the distributions of packagecloud.io
+// built into pkgcloudlib for when they can't be fetched.
+//
+// Usage, from the pkgcloudlib directory (see the go:generate directive in pkgcloud.go):
+//
+//	go run ./gendistros [-in distributions.json] [-o distros.go]
+//
+// The distributions are fetched with the API token from PACKAGECLOUD_TOKEN, unless -in names
+// a copy of https://packagecloud.io/api/v1/distributions.json. With -empty, the snapshot has no
+// distributions. It doesn't use pkgcloudlib, which can't build without distros.go.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// distributionsURL - where packagecloud.io serves its distributions
+const distributionsURL = "https://packagecloud.io/api/v1/distributions.json"
+
+func main() {
+	in := flag.String("in", "", "read the distributions from this file instead of fetching them")
+	out := flag.String("o", "distros.go", "file to write")
+	empty := flag.Bool("empty", false, "write a snapshot without distributions")
+	flag.Parse()
+
+	var snapshot string
+	var err error
+	switch {
+	case *empty:
+	case *in != "":
+		snapshot, err = readSnapshot(*in)
+	default:
+		snapshot, err = fetchSnapshot()
+	}
+	if err != nil {
+		log.Fatalf("gendistros: %s", err)
+	}
+	src, err := format.Source(generate(snapshot, time.Now()))
+	if err != nil {
+		log.Fatalf("gendistros: %s", err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("gendistros: %s", err)
+	}
+}
+
+// readSnapshot - the distributions in the file path, as indented JSON
+func readSnapshot(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return indent(data)
+}
+
+// fetchSnapshot - the distributions of packagecloud.io, as indented JSON
+func fetchSnapshot() (string, error) {
+	token := os.Getenv("PACKAGECLOUD_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("PACKAGECLOUD_TOKEN unset")
+	}
+	req, err := http.NewRequest("GET", distributionsURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(token, "")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", distributionsURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return indent(data)
+}
+
+// indent - the distributions in data, as indented JSON, after checking it has some
+func indent(data []byte) (string, error) {
+	var d map[string][]json.RawMessage
+	if err := json.Unmarshal(data, &d); err != nil {
+		return "", fmt.Errorf("invalid distributions: %s", err)
+	}
+	if len(d["deb"])+len(d["dsc"])+len(d["rpm"]) == 0 {
+		return "", fmt.Errorf("no distributions")
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// generate - the source of distros.go for snapshot, taken at t
+func generate(snapshot string, t time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gendistros; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package pkgcloudlib\n\n")
+	if snapshot == "" {
+		fmt.Fprintf(&buf, "// builtinDistributionsJSON - snapshot of the distributions of packagecloud.io, see CachedDistributions.\n")
+		fmt.Fprintf(&buf, "// This one is empty: regenerate it with go generate and an API token to have one.\n")
+		fmt.Fprintf(&buf, "const builtinDistributionsJSON = \"\"\n")
+		return buf.Bytes()
+	}
+	literal := "`" + snapshot + "`"
+	if strings.Contains(snapshot, "`") {
+		literal = strconv.Quote(snapshot)
+	}
+	fmt.Fprintf(&buf, "// builtinDistributionsJSON - snapshot of the distributions of packagecloud.io, see CachedDistributions.\n")
+	fmt.Fprintf(&buf, "// Taken on %s.\n", t.UTC().Format("2006-01-02"))
+	fmt.Fprintf(&buf, "const builtinDistributionsJSON = %s\n", literal)
+	return buf.Bytes()
+}
@@ -0,0 +1,439 @@
+// Package gpgkey reads the information needed to manage repository signing
+// keys - fingerprints, creation and expiration times - out of OpenPGP public
+// keys (RFC 4880), armored or not.
+package gpgkey
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OpenPGP packet tags
+const (
+	tagSignature       = 2
+	tagPublicKey       = 6
+	tagUserID          = 13
+	tagPublicSubkey    = 14
+	armorHeader        = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	armorFooter        = "-----END PGP PUBLIC KEY BLOCK-----"
+	subpacketCreated   = 2
+	subpacketKeyExpiry = 9
+	subpacketIssuer    = 16
+	subpacketIssuerFpr = 33
+)
+
+// minKeyLength - the shortest public key packet body of each key version: the fixed fields
+// before the algorithm specific key material
+var minKeyLength = map[byte]int{
+	2: 8,  // version, creation time, validity days, algorithm
+	3: 8,  // version, creation time, validity days, algorithm
+	4: 6,  // version, creation time, algorithm
+	5: 10, // version, creation time, algorithm, key material length
+	6: 10, // version, creation time, algorithm, key material length
+}
+
+// Key - an OpenPGP public key
+type Key struct {
+	// Fingerprint - the fingerprint of the key, as upper case hex
+	Fingerprint string
+	// KeyID - the long key id of the key, as upper case hex
+	KeyID string
+	// Created - when the key was created
+	Created time.Time
+	// Expires - when the key expires, zero if it never does
+	Expires time.Time
+	// UserIDs - the user ids of a primary key, e.g. "Jane Doe <jane@example.com>"
+	UserIDs []string
+	// Subkeys - the subkeys of a primary key
+	Subkeys []*Key
+
+	// signed - creation time of the self-signature Expires comes from
+	signed time.Time
+}
+
+// Expired - whether k, or one of its subkeys, expires before t
+func (k *Key) Expired(t time.Time) bool {
+	if !k.Expires.IsZero() && k.Expires.Before(t) {
+		return true
+	}
+	for _, sub := range k.Subkeys {
+		if sub.Expired(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse - the primary keys of the armored or binary OpenPGP public keys in data
+func Parse(data []byte) ([]*Key, error) {
+	if bytes.Contains(data, []byte(armorHeader)) {
+		var err error
+		data, err = Dearmor(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var keys []*Key
+	var current *Key // key the following signatures are about
+	var primary *Key
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		tag, body, err := readPacket(r)
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case tagPublicKey:
+			key, err := parsePublicKey(body)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			primary, current = key, key
+		case tagPublicSubkey:
+			if primary == nil {
+				return nil, errors.New("gpgkey: subkey without primary key")
+			}
+			key, err := parsePublicKey(body)
+			if err != nil {
+				return nil, err
+			}
+			primary.Subkeys = append(primary.Subkeys, key)
+			current = key
+		case tagUserID:
+			if primary != nil {
+				primary.UserIDs = append(primary.UserIDs, string(body))
+			}
+		case tagSignature:
+			if current != nil {
+				applySignature(current, primary, body)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("gpgkey: no public key found")
+	}
+	return keys, nil
+}
+
+// readPacket - reads the next packet from r, in either the old or the new format
+func readPacket(r *bytes.Reader) (tag int, body []byte, err error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	if b&0x80 == 0 {
+		return 0, nil, errors.New("gpgkey: invalid packet header")
+	}
+	var length int64
+	if b&0x40 != 0 {
+		tag = int(b & 0x3f)
+		l1, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch {
+		case l1 < 192:
+			length = int64(l1)
+		case l1 < 224:
+			l2, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = (int64(l1)-192)<<8 + int64(l2) + 192
+		case l1 == 255:
+			var l uint32
+			if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+				return 0, nil, err
+			}
+			length = int64(l)
+		default:
+			return 0, nil, errors.New("gpgkey: partial body lengths are not supported in keys")
+		}
+	} else {
+		tag = int(b>>2) & 0xf
+		switch b & 3 {
+		case 0:
+			l, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = int64(l)
+		case 1:
+			var l uint16
+			if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+				return 0, nil, err
+			}
+			length = int64(l)
+		case 2:
+			var l uint32
+			if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+				return 0, nil, err
+			}
+			length = int64(l)
+		default:
+			length = int64(r.Len())
+		}
+	}
+	if length > int64(r.Len()) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	body = make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return tag, body, err
+}
+
+// parsePublicKey - the key described by the body of a public key or subkey packet
+func parsePublicKey(body []byte) (*Key, error) {
+	if len(body) == 0 {
+		return nil, errors.New("gpgkey: empty public key packet")
+	}
+	if min, ok := minKeyLength[body[0]]; !ok {
+		return nil, fmt.Errorf("gpgkey: unsupported key version %d", body[0])
+	} else if len(body) < min {
+		return nil, fmt.Errorf("gpgkey: version %d public key packet too short", body[0])
+	}
+	key := &Key{Created: time.Unix(int64(binary.BigEndian.Uint32(body[1:5])), 0).UTC()}
+	var fingerprint []byte
+	switch version := body[0]; version {
+	case 2, 3:
+		// The fingerprint of these obsolete keys is computed from the RSA key material,
+		// they aren't worth the trouble
+		if days := binary.BigEndian.Uint16(body[5:7]); days > 0 {
+			key.Expires = key.Created.AddDate(0, 0, int(days))
+		}
+		return key, nil
+	case 4:
+		h := sha1.New()
+		h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+		h.Write(body)
+		fingerprint = h.Sum(nil)
+		key.KeyID = strings.ToUpper(hex.EncodeToString(fingerprint[len(fingerprint)-8:]))
+	case 5, 6:
+		prefix := byte(0x9a)
+		if version == 6 {
+			prefix = 0x9b
+		}
+		h := sha256.New()
+		h.Write([]byte{prefix, byte(len(body) >> 24), byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))})
+		h.Write(body)
+		fingerprint = h.Sum(nil)
+		key.KeyID = strings.ToUpper(hex.EncodeToString(fingerprint[:8]))
+	}
+	key.Fingerprint = strings.ToUpper(hex.EncodeToString(fingerprint))
+	return key, nil
+}
+
+// applySignature - records the key expiration time of a self-signature over key by primary.
+// Signatures issued by other keys, like certifications of the user ids by third parties, are ignored.
+// The most recent self-signature wins.
+func applySignature(key, primary *Key, body []byte) {
+	if len(body) < 6 || (body[0] != 4 && body[0] != 5 && body[0] != 6) {
+		return
+	}
+	sigType := body[1]
+	switch {
+	case sigType >= 0x10 && sigType <= 0x13: // certifications of a user id
+	case sigType == 0x18 || sigType == 0x1f: // subkey binding, direct key
+	default:
+		return
+	}
+	hashed, unhashed, ok := signatureSubpackets(body)
+	if !ok {
+		return
+	}
+	var created time.Time
+	var expiry uint32
+	hasExpiry := false
+	selfSigned := false
+	err := forEachSubpacket(hashed, func(typ byte, data []byte) {
+		switch typ {
+		case subpacketCreated:
+			if len(data) == 4 {
+				created = time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC()
+			}
+		case subpacketKeyExpiry:
+			if len(data) == 4 {
+				expiry = binary.BigEndian.Uint32(data)
+				hasExpiry = true
+			}
+		case subpacketIssuer, subpacketIssuerFpr:
+			selfSigned = selfSigned || issuedBy(primary, typ, data)
+		}
+	})
+	if err != nil {
+		return
+	}
+	// The issuer key id is usually unhashed, it's only a hint of which key to check the signature with
+	if err := forEachSubpacket(unhashed, func(typ byte, data []byte) {
+		if typ == subpacketIssuer || typ == subpacketIssuerFpr {
+			selfSigned = selfSigned || issuedBy(primary, typ, data)
+		}
+	}); err != nil || !selfSigned {
+		return
+	}
+	if created.Before(key.signed) {
+		return
+	}
+	key.signed = created
+	key.Expires = time.Time{}
+	if hasExpiry && expiry > 0 {
+		key.Expires = key.Created.Add(time.Duration(expiry) * time.Second)
+	}
+}
+
+// signatureSubpackets - the hashed and unhashed subpacket areas of the body of a v4, v5 or v6 signature packet
+func signatureSubpackets(body []byte) (hashed, unhashed []byte, ok bool) {
+	// v6 signatures have 4 byte subpacket area lengths, older ones 2 byte
+	size := 2
+	if body[0] == 6 {
+		size = 4
+	}
+	area := func(b []byte) ([]byte, []byte, bool) {
+		if len(b) < size {
+			return nil, nil, false
+		}
+		n := int(binary.BigEndian.Uint16(b[:2]))
+		if size == 4 {
+			n = int(binary.BigEndian.Uint32(b[:4]))
+		}
+		if n < 0 || size+n > len(b) {
+			return nil, nil, false
+		}
+		return b[size : size+n], b[size+n:], true
+	}
+	hashed, rest, ok := area(body[4:])
+	if !ok {
+		return nil, nil, false
+	}
+	unhashed, _, ok = area(rest)
+	return hashed, unhashed, ok
+}
+
+// forEachSubpacket - calls f with the type, without the critical bit, and the data of each
+// signature subpacket in area
+func forEachSubpacket(area []byte, f func(typ byte, data []byte)) error {
+	for len(area) > 0 {
+		length, n := subpacketLength(area)
+		if n == 0 || n+length > len(area) || length == 0 {
+			return errors.New("gpgkey: invalid signature subpacket")
+		}
+		data := area[n : n+length]
+		area = area[n+length:]
+		f(data[0]&0x7f, data[1:])
+	}
+	return nil
+}
+
+// issuedBy - whether the issuer key id or issuer fingerprint subpacket data of type typ is of key
+func issuedBy(key *Key, typ byte, data []byte) bool {
+	switch {
+	case key == nil:
+		return false
+	case typ == subpacketIssuer:
+		return key.KeyID != "" && strings.EqualFold(hex.EncodeToString(data), key.KeyID)
+	case typ == subpacketIssuerFpr && len(data) > 1:
+		// A key version byte, then the fingerprint
+		return key.Fingerprint != "" && strings.EqualFold(hex.EncodeToString(data[1:]), key.Fingerprint)
+	}
+	return false
+}
+
+// subpacketLength - the length of the signature subpacket at the start of b,
+// and the number of bytes encoding it
+func subpacketLength(b []byte) (int, int) {
+	switch {
+	case b[0] < 192:
+		return int(b[0]), 1
+	case b[0] < 255:
+		if len(b) < 2 {
+			return 0, 0
+		}
+		return (int(b[0])-192)<<8 + int(b[1]) + 192, 2
+	default:
+		if len(b) < 5 {
+			return 0, 0
+		}
+		return int(binary.BigEndian.Uint32(b[1:5])), 5
+	}
+}
+
+// Dearmor - the binary form of the first ASCII armored public key block in data
+func Dearmor(data []byte) ([]byte, error) {
+	start := bytes.Index(data, []byte(armorHeader))
+	if start < 0 {
+		return nil, errors.New("gpgkey: no armored public key block found")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data[start+len(armorHeader):]))
+	inHeaders := true
+	var encoded strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, armorFooter):
+			decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+			if err != nil {
+				return nil, fmt.Errorf("gpgkey: invalid armor: %s", err)
+			}
+			return decoded, nil
+		case inHeaders:
+			// Armor headers, like "Version: ...", end with an empty line
+			if line == "" || !strings.Contains(line, ": ") {
+				inHeaders = false
+				encoded.WriteString(line)
+			}
+		case strings.HasPrefix(line, "="):
+			// CRC24 checksum
+		default:
+			encoded.WriteString(line)
+		}
+	}
+	return nil, errors.New("gpgkey: unterminated armored public key block")
+}
+
+// Armor - data, a binary public key, in ASCII armored form
+func Armor(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(armorHeader + "\n\n")
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 64 {
+		buf.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	if encoded != "" {
+		buf.WriteString(encoded + "\n")
+	}
+	crc := crc24(data)
+	buf.WriteString("=" + base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}) + "\n")
+	buf.WriteString(armorFooter + "\n")
+	return buf.Bytes()
+}
+
+// crc24 - the checksum of armored data, see RFC 4880 section 6.1
+func crc24(data []byte) uint32 {
+	const (
+		crc24Init = 0xb704ce
+		crc24Poly = 0x1864cfb
+	)
+	crc := uint32(crc24Init)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24Poly
+			}
+		}
+	}
+	return crc & 0xffffff
+}
@@ -0,0 +1,214 @@
+package gpgkey
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// certified.asc - an ed25519 key created 2020-01-01 that expires after 2 years, with a cv25519 subkey
+// that expires after 1, and a certification of its user id by another key made in 2021
+const (
+	certifiedFingerprint       = "2385FEE886919DA3299566E13A236043D6D87CBA"
+	certifiedKeyID             = "3A236043D6D87CBA"
+	certifiedSubkeyFingerprint = "F0B455422902C4F72D7110DDF5697F08263EC607"
+)
+
+func TestParse(t *testing.T) {
+	armored, err := ioutil.ReadFile("testdata/certified.asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := Dearmor(armored)
+	if err != nil {
+		t.Fatalf("Dearmor: %s", err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "armored", data: armored},
+		{name: "binary", data: raw},
+		{name: "rearmored", data: Armor(raw)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Parse: %s", err)
+			}
+			if len(keys) != 1 {
+				t.Fatalf("Parse: %d keys, want 1", len(keys))
+			}
+			key := keys[0]
+			if key.Fingerprint != certifiedFingerprint || key.KeyID != certifiedKeyID {
+				t.Errorf("Fingerprint, KeyID = %s, %s", key.Fingerprint, key.KeyID)
+			}
+			if want := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC); !key.Created.Equal(want) {
+				t.Errorf("Created = %s, want %s", key.Created, want)
+			}
+			// The later third party certification must not clear the expiration of the self-signature
+			if want := time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC); !key.Expires.Equal(want) {
+				t.Errorf("Expires = %s, want %s", key.Expires, want)
+			}
+			if want := []string{"Repo Signing <repo@example.com>"}; !reflect.DeepEqual(key.UserIDs, want) {
+				t.Errorf("UserIDs = %q, want %q", key.UserIDs, want)
+			}
+			if len(key.Subkeys) != 1 {
+				t.Fatalf("%d subkeys, want 1", len(key.Subkeys))
+			}
+			sub := key.Subkeys[0]
+			if sub.Fingerprint != certifiedSubkeyFingerprint {
+				t.Errorf("subkey Fingerprint = %s", sub.Fingerprint)
+			}
+			if want := time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC); !sub.Expires.Equal(want) {
+				t.Errorf("subkey Expires = %s, want %s", sub.Expires, want)
+			}
+			if key.Expired(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Error("Expired in 2020-06")
+			}
+			if !key.Expired(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Error("not Expired in 2021-06, after the subkey expired")
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "not a packet", data: []byte("hello")},
+		{name: "truncated packet", data: []byte{0xc6, 0x20, 4, 0, 0, 0}},
+		{name: "unterminated armor", data: []byte(armorHeader + "\n\nmDMEXgvhABYJKwYBBAHaRw8BAQdA\n")},
+		{name: "subkey first", data: []byte{0xce, 6, 4, 0, 0, 0, 0, 22}},
+		{name: "user id only", data: []byte{0xcd, 1, 'a'}},
+	}
+	for _, tt := range tests {
+		if keys, err := Parse(tt.data); err == nil {
+			t.Errorf("%s: Parse = %v, want an error", tt.name, keys)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		err  bool
+	}{
+		{name: "empty", body: nil, err: true},
+		{name: "v3 without validity", body: []byte{3, 0, 0, 0, 1, 0}, err: true},
+		{name: "v3 without algorithm", body: []byte{3, 0, 0, 0, 1, 0, 1}, err: true},
+		{name: "v3", body: []byte{3, 0, 0, 0, 1, 0, 1, 1}},
+		{name: "v4 without algorithm", body: []byte{4, 0, 0, 0, 1}, err: true},
+		{name: "v4", body: []byte{4, 0, 0, 0, 1, 22}},
+		{name: "v5 without key material length", body: []byte{5, 0, 0, 0, 1, 22, 0, 0}, err: true},
+		{name: "v5", body: []byte{5, 0, 0, 0, 1, 22, 0, 0, 0, 0}},
+		{name: "v6 without key material length", body: []byte{6, 0, 0, 0, 1, 27}, err: true},
+		{name: "v6", body: []byte{6, 0, 0, 0, 1, 27, 0, 0, 0, 0}},
+		{name: "v7", body: []byte{7, 0, 0, 0, 1, 22, 0, 0, 0, 0}, err: true},
+	}
+	for _, tt := range tests {
+		key, err := parsePublicKey(tt.body)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: parsePublicKey error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !key.Created.Equal(time.Unix(1, 0)) {
+			t.Errorf("%s: Created = %s", tt.name, key.Created)
+		}
+		if tt.body[0] == 3 && !key.Expires.Equal(key.Created.AddDate(0, 0, 1)) {
+			t.Errorf("%s: Expires = %s", tt.name, key.Expires)
+		}
+	}
+}
+
+func TestApplySignature(t *testing.T) {
+	primary := &Key{
+		Fingerprint: certifiedFingerprint,
+		KeyID:       certifiedKeyID,
+		Created:     time.Unix(1000, 0).UTC(),
+	}
+	fingerprint, _ := hex.DecodeString(certifiedFingerprint)
+	keyID, _ := hex.DecodeString(certifiedKeyID)
+	otherKeyID, _ := hex.DecodeString("69FE501942D1109F")
+	tests := []struct {
+		name     string
+		hashed   [][]byte
+		unhashed [][]byte
+		applied  bool
+	}{
+		{
+			name:    "issuer fingerprint",
+			hashed:  [][]byte{issuerFprSubpacket(fingerprint)},
+			applied: true,
+		},
+		{
+			name:     "unhashed issuer key id",
+			unhashed: [][]byte{subpacket(subpacketIssuer, keyID)},
+			applied:  true,
+		},
+		{
+			name:     "other issuer",
+			unhashed: [][]byte{subpacket(subpacketIssuer, otherKeyID)},
+		},
+		{
+			name: "no issuer",
+		},
+		{
+			name:     "invalid unhashed subpacket",
+			hashed:   [][]byte{issuerFprSubpacket(fingerprint)},
+			unhashed: [][]byte{{5, subpacketIssuer}},
+		},
+	}
+	for _, tt := range tests {
+		key := &Key{Created: primary.Created}
+		hashed := append(tt.hashed, subpacket(subpacketCreated, uint32Bytes(2000)), subpacket(subpacketKeyExpiry, uint32Bytes(3600)))
+		applySignature(key, primary, signature(0x18, hashed, tt.unhashed))
+		if applied := !key.Expires.IsZero(); applied != tt.applied {
+			t.Errorf("%s: applied = %v, want %v", tt.name, applied, tt.applied)
+		}
+		if tt.applied && !key.Expires.Equal(key.Created.Add(time.Hour)) {
+			t.Errorf("%s: Expires = %s", tt.name, key.Expires)
+		}
+	}
+}
+
+// signature - the body of a v4 signature packet of type sigType with the subpackets hashed and unhashed
+func signature(sigType byte, hashed, unhashed [][]byte) []byte {
+	body := []byte{4, sigType, 22, 8}
+	for _, area := range [][][]byte{hashed, unhashed} {
+		var b []byte
+		for _, sp := range area {
+			b = append(b, sp...)
+		}
+		body = append(body, byte(len(b)>>8), byte(len(b)))
+		body = append(body, b...)
+	}
+	// Hash prefix and signature material, not looked at
+	return append(body, 0, 0)
+}
+
+// subpacket - a signature subpacket of type typ with data
+func subpacket(typ byte, data []byte) []byte {
+	return append([]byte{byte(len(data) + 1), typ}, data...)
+}
+
+func issuerFprSubpacket(fingerprint []byte) []byte {
+	return subpacket(subpacketIssuerFpr, append([]byte{4}, fingerprint...))
+}
+
+func uint32Bytes(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
@@ -0,0 +1,104 @@
+package pkgcloudlib
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/upload"
+)
+
+// GPGKey - packagecloud.io GPG key of a repository, signing either the packages or the repository metadata
+// See https://packagecloud.io/docs/api#resource_gpg_keys
+type GPGKey struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	KeyID       string    `json:"keyid"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	KeyType     string    `json:"key_type"`
+	DownloadURL string    `json:"download_url"`
+}
+
+// GPGKeys - retrieve the GPG keys of repo
+func (c *Client) GPGKeys(repo string) ([]*GPGKey, error) {
+	return c.GPGKeysContext(context.Background(), repo)
+}
+
+// GPGKeysContext is like GPGKeys, but with a context.
+func (c *Client) GPGKeysContext(ctx context.Context, repo string) ([]*GPGKey, error) {
+	req, err := c.newRequest(ctx, "GET", c.apiURL("repos/%s/gpg_keys.json", repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	var keys struct {
+		GPGKeys []*GPGKey `json:"gpg_keys"`
+	}
+	err = c.do(req, &keys)
+	if err != nil {
+		return nil, err
+	}
+	return keys.GPGKeys, nil
+}
+
+// UploadGPGKey - upload keydata, an armored GPG public key, to repo to sign its packages
+func (c *Client) UploadGPGKey(repo string, keydata []byte) (*GPGKey, error) {
+	return c.UploadGPGKeyContext(context.Background(), repo, keydata)
+}
+
+// UploadGPGKeyContext is like UploadGPGKey, but with a context.
+func (c *Client) UploadGPGKeyContext(ctx context.Context, repo string, keydata []byte) (*GPGKey, error) {
+	form := &upload.Form{
+		FieldName: "gpg_key[keydata]",
+		File:      upload.ReaderFile("gpg.key", bytes.NewReader(keydata), int64(len(keydata))),
+	}
+	req, err := form.NewRequest(ctx, c.apiURL("repos/%s/gpg_keys.json", repo))
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+	key := &GPGKey{}
+	err = c.do(req, key)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DestroyGPGKey - delete the GPG key of repo with the given key id
+func (c *Client) DestroyGPGKey(repo, keyID string) error {
+	return c.DestroyGPGKeyContext(context.Background(), repo, keyID)
+}
+
+// DestroyGPGKeyContext is like DestroyGPGKey, but with a context.
+func (c *Client) DestroyGPGKeyContext(ctx context.Context, repo, keyID string) error {
+	req, err := c.newRequest(ctx, "DELETE", c.apiURL("repos/%s/gpg_keys/%s", repo, keyID), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, &struct{}{})
+}
+
+// GPGKeyData - download the public key of key, as served by packagecloud.io (usually armored)
+// See the gpgkey package to read it
+func (c *Client) GPGKeyData(key *GPGKey) ([]byte, error) {
+	return c.GPGKeyDataContext(context.Background(), key)
+}
+
+// GPGKeyDataContext is like GPGKeyData, but with a context.
+func (c *Client) GPGKeyDataContext(ctx context.Context, key *GPGKey) ([]byte, error) {
+	req, err := c.newRequest(ctx, "GET", c.resolveURL(key.DownloadURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,198 @@
+package pkgcloudlib
+
+import (
+	"context"
+)
+
+// Iterator iterates over the items of a paginated resource, fetching the
+// pages lazily. Use it like:
+//
+//	it := client.PackagesContext(ctx, "user/repo", &ListOptions{Prefetch: 4})
+//	defer it.Close()
+//	for it.Next() {
+//		p := it.Package()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	client   *Client
+	endpoint string
+	opts     ListOptions
+
+	started bool
+	items   []T
+	current T
+	err     error
+
+	// next follows the Link headers when pages are not prefetched
+	next func() (*Page[T], error)
+	// pages receive the prefetched pages, in order
+	pages []chan pageResult[T]
+	// inflight bounds the number of pages fetched ahead
+	inflight chan struct{}
+	// filter, when set, drops the items it returns false for
+	filter func(T) bool
+}
+
+type pageResult[T any] struct {
+	page *Page[T]
+	err  error
+}
+
+// NewIterator returns an iterator over the items of the paginated resource at
+// endpoint. opts may be nil.
+//
+// With opts.Prefetch > 0, once the first page tells how many pages there are,
+// up to opts.Prefetch of the following pages are fetched concurrently while
+// the current one is consumed.
+func NewIterator[T any](ctx context.Context, c *Client, endpoint string, opts *ListOptions) *Iterator[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &Iterator[T]{
+		ctx:      ctx,
+		cancel:   cancel,
+		client:   c,
+		endpoint: endpoint,
+	}
+	if opts != nil {
+		it.opts = *opts
+	}
+	return it
+}
+
+// Next advances to the next item, fetching pages as needed. It returns
+// false at the end of the items or on error.
+func (it *Iterator[T]) Next() bool {
+	for {
+		for len(it.items) == 0 {
+			if !it.fetch() {
+				var zero T
+				it.current = zero
+				it.Close()
+				return false
+			}
+		}
+		it.current, it.items = it.items[0], it.items[1:]
+		if it.filter == nil || it.filter(it.current) {
+			return true
+		}
+	}
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close stops fetching pages. It must be called when the iteration is
+// abandoned before Next returns false.
+func (it *Iterator[T]) Close() {
+	it.cancel()
+}
+
+// fetch fetches the next page into it.items, and returns false when there
+// is none.
+func (it *Iterator[T]) fetch() bool {
+	if it.err != nil {
+		return false
+	}
+	var page *Page[T]
+	var err error
+	switch {
+	case !it.started:
+		it.started = true
+		page, err = GetPage[T](it.ctx, it.client, it.endpoint, &it.opts)
+		if err == nil {
+			it.next = page.Next
+			if it.opts.Prefetch > 0 && page.Next != nil && page.PerPage > 0 {
+				first := it.opts.Page
+				if first < 1 {
+					first = 1
+				}
+				it.prefetchPages(page.url, first+1, (page.Total+page.PerPage-1)/page.PerPage)
+			}
+		}
+	case it.pages != nil:
+		if len(it.pages) == 0 {
+			return false
+		}
+		select {
+		case r := <-it.pages[0]:
+			page, err = r.page, r.err
+			// Let the next page be fetched. Only pages that were started hold a token,
+			// after a cancellation the following ones may never get one.
+			<-it.inflight
+		case <-it.ctx.Done():
+			err = it.ctx.Err()
+		}
+		it.pages = it.pages[1:]
+	case it.next != nil:
+		page, err = it.next()
+		if err == nil {
+			it.next = page.Next
+		}
+	default:
+		return false
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.items = page.Items
+	return true
+}
+
+// prefetchPages starts fetching pages from to last of the resource at
+// endpoint in the background.
+func (it *Iterator[T]) prefetchPages(endpoint string, from, last int) {
+	if from > last {
+		return
+	}
+	it.pages = make([]chan pageResult[T], last-from+1)
+	for i := range it.pages {
+		it.pages[i] = make(chan pageResult[T], 1)
+	}
+	it.inflight = make(chan struct{}, it.opts.Prefetch)
+	pages := it.pages
+	go func() {
+		for i, ch := range pages {
+			select {
+			case it.inflight <- struct{}{}:
+			case <-it.ctx.Done():
+				return
+			}
+			go func(number int, ch chan<- pageResult[T]) {
+				page, err := getPage[T](it.ctx, it.client, setQuery(endpoint, "page", number))
+				ch <- pageResult[T]{page, err}
+			}(from+i, ch)
+		}
+	}()
+}
+
+// PackageIterator iterates over packages across pages.
+type PackageIterator struct {
+	*Iterator[*Package]
+}
+
+// Package returns the current package.
+func (it PackageIterator) Package() *Package {
+	return it.Item()
+}
+
+// Packages returns an iterator over all the packages of repo. opts may be nil.
+func (c *Client) Packages(repo string, opts *ListOptions) PackageIterator {
+	return c.PackagesContext(context.Background(), repo, opts)
+}
+
+// PackagesContext is like Packages, but with a context.
+func (c *Client) PackagesContext(ctx context.Context, repo string, opts *ListOptions) PackageIterator {
+	return PackageIterator{NewIterator[*Package](ctx, c, c.apiURL("repos/%s/packages.json", repo), opts)}
+}
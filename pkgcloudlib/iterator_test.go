@@ -0,0 +1,95 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagesServer - a server of pages of one package each, pages of total. The pages after the first
+// are only served once release is closed.
+func pagesServer(total int, release <-chan struct{}) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		if page > 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Total", strconv.Itoa(total))
+		w.Header().Set("Per-Page", "1")
+		w.Header().Set("Max-Per-Page", "1")
+		if page < total {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next"`, srv.URL, r.URL.Path, page+1))
+		}
+		fmt.Fprintf(w, `[{"name":"p%d"}]`, page)
+	}))
+	return srv
+}
+
+func TestIteratorPrefetch(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	srv := pagesServer(10, release)
+	defer srv.Close()
+	client, err := New(WithToken("token"), WithURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefetch := range []int{0, 1, 3, 20} {
+		it := client.PackagesContext(context.Background(), "a/b", &ListOptions{Prefetch: prefetch})
+		n := 0
+		for it.Next() {
+			n++
+			if want := fmt.Sprintf("p%d", n); it.Package().Name != want {
+				t.Errorf("prefetch %d: package %d = %s, want %s", prefetch, n, it.Package().Name, want)
+			}
+		}
+		if err := it.Err(); err != nil || n != 10 {
+			t.Errorf("prefetch %d: %d packages, error %v, want 10 packages", prefetch, n, err)
+		}
+	}
+}
+
+func TestIteratorCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := pagesServer(10, release)
+	defer srv.Close()
+	defer close(release)
+	client, err := New(WithToken("token"), WithURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Whether the prefetching started any page when the iterator is cancelled is up to the scheduler
+	for i := 0; i < 200; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		it := client.PackagesContext(ctx, "a/b", &ListOptions{Prefetch: 2})
+		if !it.Next() {
+			t.Fatalf("no first package: %v", it.Err())
+		}
+		cancel()
+		done := make(chan bool)
+		go func() {
+			done <- it.Next()
+		}()
+		select {
+		case more := <-done:
+			if more || !errors.Is(it.Err(), context.Canceled) {
+				t.Fatalf("Next after cancel = %v, error %v, want false, %v", more, it.Err(), context.Canceled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("run %d: Next blocked after cancel", i)
+		}
+	}
+}
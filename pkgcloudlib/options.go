@@ -0,0 +1,111 @@
+package pkgcloudlib
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-errors/errors"
+
+	"github.com/edwarnicke/pkgcloud/pkgcloudlib/upload"
+)
+
+// Option configures a Client created with New.
+type Option func(*Client) error
+
+// WithToken sets the API token used to authenticate requests.
+func WithToken(token string) Option {
+	return func(c *Client) error {
+		c.Token = token
+		return nil
+	}
+}
+
+// WithURL sets the base URL of the packagecloud instance to talk to,
+// e.g. a packagecloud:enterprise installation.
+func WithURL(url string) Option {
+	return func(c *Client) error {
+		if url == "" {
+			return errors.New("empty packagecloud URL")
+		}
+		c.URL = url
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("nil http.Client")
+		}
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to send requests.
+// It can be combined with WithHTTPClient, in which case the transport
+// replaces the one of the given http.Client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) error {
+		if rt == nil {
+			return errors.New("nil http.RoundTripper")
+		}
+		hc := &http.Client{}
+		if c.httpClient != nil {
+			*hc = *c.httpClient
+		}
+		hc.Transport = rt
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithUserAgent appends suffix to the UserAgent sent with every request.
+func WithUserAgent(suffix string) Option {
+	return func(c *Client) error {
+		c.userAgent = suffix
+		return nil
+	}
+}
+
+// WithTimeout bounds the time each request may take, including reading
+// the response body. Zero means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) error {
+		if d < 0 {
+			return errors.Errorf("negative timeout: %s", d)
+		}
+		c.timeout = d
+		return nil
+	}
+}
+
+// WithProgress sets a function reporting the progress of package uploads.
+func WithProgress(progress upload.ProgressFunc) Option {
+	return func(c *Client) error {
+		c.progress = progress
+		return nil
+	}
+}
+
+// WithCacheDir sets the directory the distributions are cached in, see
+// Client.CachedDistributions. An empty dir disables the cache on disk.
+func WithCacheDir(dir string) Option {
+	return func(c *Client) error {
+		c.cacheDir = dir
+		return nil
+	}
+}
+
+// WithCacheTTL sets how long the cached distributions are used before being
+// fetched again.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl < 0 {
+			return errors.Errorf("negative cache TTL: %s", ttl)
+		}
+		c.cacheTTL = ttl
+		return nil
+	}
+}
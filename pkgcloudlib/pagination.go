@@ -0,0 +1,141 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tomnomnom/linkheader"
+)
+
+// Paginated captures pagination information described at - https://packagecloud.io/docs/api#pagination
+type Paginated struct {
+	Total      int
+	PerPage    int
+	MaxPerPage int
+}
+
+// PerPageMax - ListOptions.PerPage value asking for pages of Paginated.MaxPerPage items
+const PerPageMax = -1
+
+// ListOptions - options for listing a paginated resource
+type ListOptions struct {
+	// PerPage - number of items per page: 0 for the server default,
+	// PerPageMax for the largest pages the server allows
+	PerPage int
+	// Page - number of the first page to fetch, starting at 1
+	Page int
+	// Prefetch - number of pages an Iterator fetches concurrently ahead of the current one
+	Prefetch int
+}
+
+// Page captures a page of a paginated resource together with the pagination meta data
+// Items - list of items returned in this page
+// Next - function that can be called to fetch the next page, nil on the last page
+type Page[T any] struct {
+	Items []T
+	Next  func() (*Page[T], error)
+	Paginated
+
+	url string
+}
+
+// GetPage - Gets the page of the paginated resource at endpoint selected by opts, which may be nil
+// Note: Fetching subsequent pages should be done with Page.Next()
+func GetPage[T any](ctx context.Context, c *Client, endpoint string, opts *ListOptions) (*Page[T], error) {
+	if opts != nil && opts.PerPage > 0 {
+		endpoint = setQuery(endpoint, "per_page", opts.PerPage)
+	}
+	if opts != nil && opts.Page > 0 {
+		endpoint = setQuery(endpoint, "page", opts.Page)
+	}
+	page, err := getPage[T](ctx, c, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.PerPage == PerPageMax && page.Next != nil && page.MaxPerPage > page.PerPage {
+		// Only now do we know how large pages may be, get this one again at that size
+		return getPage[T](ctx, c, setQuery(endpoint, "per_page", page.MaxPerPage))
+	}
+	return page, nil
+}
+
+func getPage[T any](ctx context.Context, c *Client, endpoint string) (*Page[T], error) {
+	rv := &Page[T]{url: endpoint}
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	err = decodeResponse(resp, &rv.Items)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.Get("Total") == "" {
+		// Not paginated after all, everything is on this page
+		rv.Total, rv.PerPage, rv.MaxPerPage = len(rv.Items), len(rv.Items), len(rv.Items)
+		return rv, nil
+	}
+	err = ExtractPaginationHeaders(&resp.Header, &rv.Paginated)
+	if err != nil {
+		return nil, err
+	}
+	if next := nextLink(resp.Header); next != "" {
+		rv.Next = func() (*Page[T], error) {
+			return getPage[T](ctx, c, next)
+		}
+	}
+	return rv, nil
+}
+
+// nextLink - the URL of the next page from the Link header, if any
+func nextLink(h http.Header) string {
+	for _, link := range linkheader.Parse(h.Get("Link")) {
+		if link.Rel == "next" {
+			return link.URL
+		}
+	}
+	return ""
+}
+
+// ExtractPaginationHeaders - Extract Paginated Object from the http.Headers
+func ExtractPaginationHeaders(h *http.Header, p *Paginated) error {
+	header := h.Get("Total")
+	total, err := strconv.Atoi(header)
+	if err != nil {
+		return err
+	}
+	p.Total = total
+
+	header = h.Get("Per-Page")
+	perPage, err := strconv.Atoi(header)
+	if err != nil {
+		return err
+	}
+	p.PerPage = perPage
+
+	header = h.Get("Max-Per-Page")
+	maxPerPage, err := strconv.Atoi(header)
+	if err != nil {
+		return err
+	}
+	p.MaxPerPage = maxPerPage
+	return nil
+}
+
+// setQuery returns endpoint with the query parameter key set to value.
+func setQuery(endpoint, key string, value int) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	q := u.Query()
+	q.Set(key, strconv.Itoa(value))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
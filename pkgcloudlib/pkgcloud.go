@@ -3,8 +3,11 @@
 package pkgcloudlib
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -17,10 +20,9 @@ import (
 	"github.com/go-errors/errors"
 
 	"github.com/edwarnicke/pkgcloud/pkgcloudlib/upload"
-	"github.com/tomnomnom/linkheader"
 )
 
-//go:generate bash -c "./gendistros.py supportedDistros | gofmt > distros.go"
+//go:generate go run ./gendistros -o distros.go
 
 // ServiceURL is the URL of packagecloud's API.
 const ServiceURL = "https://packagecloud.io/api/v1"
@@ -35,96 +37,305 @@ const UserAgent = "pkgcloud Go client"
 type Client struct {
 	URL   string `json:"url"`
 	Token string `json:"token"`
+
+	httpClient *http.Client
+	userAgent  string
+	timeout    time.Duration
+	retry      RetryPolicy
+	progress   upload.ProgressFunc
+	cacheDir   string
+	cacheTTL   time.Duration
+	catalog    *catalog
 }
 
 // NewClient creates a packagecloud client. API requests are authenticated
 // using an API token. If no token is passed, it will be read from the
-// PACKAGECLOUD_TOKEN environment variable.
+// PACKAGECLOUD_TOKEN environment variable. The packagecloud instance to talk
+// to defaults to ServiceBaseURL and can be overridden with the
+// PACKAGECLOUD_URL environment variable.
 func NewClient(token string) (*Client, error) {
-	if token == "" {
-		token = os.Getenv("PACKAGECLOUD_TOKEN")
-		if token == "" {
-			usr, err := user.Current()
-			if err != nil {
-				return nil, err
+	return New(WithToken(token))
+}
+
+// New creates a packagecloud client configured by opts. Token and URL
+// fall back to the same sources as NewClient when not given as options.
+// Requests are retried according to DefaultRetryPolicy, unless
+// WithRetryPolicy is given. The distributions are cached for DefaultCacheTTL
+// under the user cache dir, unless WithCacheDir or WithCacheTTL are given.
+func New(opts ...Option) (*Client, error) {
+	client := &Client{
+		retry:    DefaultRetryPolicy,
+		cacheDir: defaultCacheDir(),
+		cacheTTL: DefaultCacheTTL,
+		catalog:  &catalog{},
+	}
+	for _, opt := range opts {
+		if err := opt(client); err != nil {
+			return nil, err
+		}
+	}
+	if client.URL == "" {
+		client.URL = os.Getenv("PACKAGECLOUD_URL")
+	}
+	if client.Token == "" {
+		client.Token = os.Getenv("PACKAGECLOUD_TOKEN")
+	}
+	if client.Token == "" {
+		config, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		client.Token = config.Token
+		if client.URL == "" {
+			client.URL = config.URL
+		}
+	}
+	if client.URL == "" {
+		client.URL = ServiceBaseURL
+	}
+	return client, nil
+}
+
+// loadConfig reads the URL and token from ~/.packagecloud
+func loadConfig() (*Client, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return nil, err
+	}
+	pkfile := filepath.Join(usr.HomeDir, ".packagecloud")
+	if _, err := os.Stat(pkfile); err != nil {
+		return nil, errors.New("PACKAGECLOUD_TOKEN unset")
+	}
+	fd, err := os.Open(pkfile)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	config := &Client{}
+	err = json.NewDecoder(fd).Decode(config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// baseURL returns the URL of the packagecloud instance used by the client,
+// without a trailing slash.
+func (c *Client) baseURL() string {
+	if c.URL == "" {
+		return strings.TrimSuffix(ServiceBaseURL, "/")
+	}
+	return strings.TrimSuffix(c.URL, "/")
+}
+
+// apiURL returns the API endpoint for path on the client's packagecloud instance.
+func (c *Client) apiURL(format string, a ...interface{}) string {
+	return c.baseURL() + "/api/v1/" + fmt.Sprintf(format, a...)
+}
+
+// resolveURL turns a server relative URL, like the ones found in Package,
+// into an absolute one on the client's packagecloud instance.
+func (c *Client) resolveURL(ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	return c.baseURL() + "/" + strings.TrimPrefix(ref, "/")
+}
+
+// newRequest creates an authenticated request for endpoint.
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+	return req, nil
+}
+
+// setHeaders adds authentication and User-Agent to req.
+func (c *Client) setHeaders(req *http.Request) {
+	req.SetBasicAuth(c.Token, "")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", UserAgent+" "+c.userAgent)
+	} else {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+}
+
+// send sends req, retrying transient failures according to the client's
+// RetryPolicy.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendOnce(req)
+		wait, retry := c.retry.wait(req, attempt, resp, err)
+		if !retry {
+			return resp, err
+		}
+		next := req
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				// The request can't be sent again, report the last attempt
+				return resp, err
 			}
-			pkfile := filepath.Join(usr.HomeDir, ".packagecloud")
-			if _, err := os.Stat(pkfile); err == nil {
-				fd, err := os.Open(pkfile)
-				if err != nil {
-					return nil, err
-				}
-				client := &Client{}
-				err = json.NewDecoder(fd).Decode(client)
-				if err != nil {
-					return nil, err
-				}
-				return client, nil
+			next = req.Clone(req.Context())
+			next.Body = body
+		}
+		if resp != nil {
+			drain(resp)
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			if next.Body != nil {
+				next.Body.Close()
 			}
-			return nil, errors.New("PACKAGECLOUD_TOKEN unset")
+			return nil, err
 		}
+		req = next
+	}
+}
+
+// sendOnce sends req with the client's http.Client, applying the configured
+// timeout. The timeout covers reading the response body, so callers must
+// close it.
+func (c *Client) sendOnce(req *http.Request) (*http.Response, error) {
+	hc := c.httpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), c.timeout)
+		req = req.WithContext(ctx)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
-	return &Client{ServiceBaseURL, token}, nil
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
 }
 
-// decodeResponse checks http status code and tries to decode json body
+// cancelBody releases the request's timeout once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// do sends req and decodes the response into respJSON.
+func (c *Client) do(req *http.Request, respJSON interface{}) error {
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, respJSON)
+}
+
+// postJSON posts body encoded as JSON to endpoint, and decodes the response into respJSON.
+func (c *Client) postJSON(ctx context.Context, endpoint string, body, respJSON interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(ctx, "POST", endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, respJSON)
+}
+
+// postForm posts form to endpoint, and decodes the response into respJSON.
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values, respJSON interface{}) error {
+	req, err := c.newRequest(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req, respJSON)
+}
+
+// decodeResponse checks http status code and tries to decode json body.
+// Unsuccessful responses are returned as *APIError.
 func decodeResponse(resp *http.Response, respJSON interface{}) error {
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusCreated:
 		return json.NewDecoder(resp.Body).Decode(respJSON)
-	case http.StatusUnauthorized, http.StatusNotFound:
-		return fmt.Errorf("HTTP status: %s", http.StatusText(resp.StatusCode))
-	case 422: // Unprocessable Entity
-		var v map[string][]string
-		if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-			return err
-		}
-		for _, messages := range v {
-			for _, msg := range messages {
-				// Only return the very first error message
-				return errors.New(msg)
-			}
-			break
-		}
-		return fmt.Errorf("invalid HTTP body")
 	default:
-		return fmt.Errorf("unexpected HTTP status: %d", resp.StatusCode)
+		return newAPIError(resp)
 	}
 }
 
 // CreatePackage pushes a new package to packagecloud.
 func (c Client) CreatePackage(repo, distro, pkgFile string) error {
+	return c.CreatePackageContext(context.Background(), repo, distro, pkgFile)
+}
+
+// CreatePackageContext is like CreatePackage, but with a context.
+func (c Client) CreatePackageContext(ctx context.Context, repo, distro, pkgFile string) error {
+	file, err := upload.LocalFile(pkgFile)
+	if err != nil {
+		return err
+	}
+	return c.UploadPackageContext(ctx, repo, distro, file)
+}
+
+// CreatePackageFromReader pushes a new package named filename, read from r,
+// to packagecloud. size is the size of the package in bytes, or -1 if unknown.
+// See upload.ReaderFile for when the upload can be retried.
+func (c Client) CreatePackageFromReader(repo, distro, filename string, r io.Reader, size int64) error {
+	return c.CreatePackageFromReaderContext(context.Background(), repo, distro, filename, r, size)
+}
+
+// CreatePackageFromReaderContext is like CreatePackageFromReader, but with a context.
+func (c Client) CreatePackageFromReaderContext(ctx context.Context, repo, distro, filename string, r io.Reader, size int64) error {
+	return c.UploadPackageContext(ctx, repo, distro, upload.ReaderFile(filename, r, size))
+}
+
+// UploadPackage pushes file as a new package to packagecloud.
+func (c Client) UploadPackage(repo, distro string, file upload.File) error {
+	return c.UploadPackageContext(context.Background(), repo, distro, file)
+}
+
+// UploadPackageContext is like UploadPackage, but with a context.
+func (c Client) UploadPackageContext(ctx context.Context, repo, distro string, file upload.File) error {
 	var extraParams map[string]string
 	if distro != "" {
-		supportedDistros, err := c.SupportedDistros()
+		resolver, err := c.DistroResolverContext(ctx)
 		if err != nil {
 			return err
 		}
-		distID, ok := supportedDistros[distro]
-		if !ok {
-			return fmt.Errorf("invalid distro name: %s", distro)
+		distID, err := resolver.ResolveFile(file.Name, distro)
+		if err != nil {
+			return err
 		}
 		extraParams = map[string]string{
 			"package[distro_version_id]": strconv.Itoa(distID),
 		}
 	}
 
-	endpoint := fmt.Sprintf("%s/repos/%s/packages.json", ServiceURL, repo)
-	request, err := upload.NewRequest(endpoint, extraParams, "package[package_file]", pkgFile)
-	if err != nil {
-		return err
+	form := &upload.Form{
+		Params:    extraParams,
+		FieldName: "package[package_file]",
+		File:      file,
+		Progress:  c.progress,
 	}
-	request.SetBasicAuth(c.Token, "")
-	request.Header.Add("User-Agent", UserAgent)
-
-	client := &http.Client{}
-	resp, err := client.Do(request)
+	endpoint := c.apiURL("repos/%s/packages.json", repo)
+	request, err := form.NewRequest(ctx, endpoint)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	c.setHeaders(request)
 
-	return decodeResponse(resp, &struct{}{})
+	return c.do(request, &struct{}{})
 }
 
 // Package - packagcloud.io Package structure
@@ -157,60 +368,44 @@ type Package struct {
 // repo should be full path to repository
 // (e.g. youruser/repository/ubuntu/xenial).
 func (c Client) Destroy(repo, packageFilename string) error {
-	endpoint := fmt.Sprintf("%s/repos/%s/%s", ServiceURL, repo, packageFilename)
-
-	req, err := http.NewRequest("DELETE", endpoint, nil)
-	if err != nil {
-		return err
-	}
+	return c.DestroyContext(context.Background(), repo, packageFilename)
+}
 
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
+// DestroyContext is like Destroy, but with a context.
+func (c Client) DestroyContext(ctx context.Context, repo, packageFilename string) error {
+	endpoint := c.apiURL("repos/%s/%s", repo, packageFilename)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	req, err := c.newRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	return decodeResponse(resp, &struct{}{})
+	return c.do(req, &struct{}{})
 }
 
 // DestroyFromPackage removes package from repository.
 //
 // For use with Package struct
 func (c Client) DestroyFromPackage(p *Package) error {
-	endpoint := fmt.Sprintf("%s/%s", ServiceBaseURL, p.DestroyURL)
-
-	req, err := http.NewRequest("DELETE", endpoint, nil)
-	if err != nil {
-		return err
-	}
+	return c.DestroyFromPackageContext(context.Background(), p)
+}
 
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
+// DestroyFromPackageContext is like DestroyFromPackage, but with a context.
+func (c Client) DestroyFromPackageContext(ctx context.Context, p *Package) error {
+	endpoint := c.resolveURL(p.DestroyURL)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	req, err := c.newRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	return decodeResponse(resp, &struct{}{})
-}
-
-// Paginated captures pagination information described at - https://packagecloud.io/docs/api#pagination
-type Paginated struct {
-	Total      int
-	PerPage    int
-	MaxPerPage int
+	return c.do(req, &struct{}{})
 }
 
 // PaginatedPackages captures 'Package' and pagination together
 // Packages - list of packages returned in this page
-// Next - function that can be called to fetch the nexts set of pages
+// Next - function that can be called to fetch the nexts set of pages, using the
+// context the first page was fetched with
 // Paginated - Pagination meta data about this set of packages
 type PaginatedPackages struct {
 	Packages []*Package
@@ -221,92 +416,62 @@ type PaginatedPackages struct {
 // GetPaginatedPackages - Gets the first set of PaginatedPackages for endpoint
 // Note: Fetching subsequent packages should be done with PackaginesPackages.Next()
 func (c *Client) GetPaginatedPackages(endpoint string) (*PaginatedPackages, error) {
-	rv := &PaginatedPackages{}
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	err = decodeResponse(resp, &rv.Packages)
-	if err != nil {
-		return nil, err
-	}
-	err = ExtractPaginationHeaders(&resp.Header, &rv.Paginated)
-	if err != nil {
-		return nil, err
-	}
-	header := resp.Header.Get("Link")
-	links := linkheader.Parse(header)
-	for _, link := range links {
-		if link.Rel == "next" {
-			rv.Next = func() (*PaginatedPackages, error) {
-				return c.GetPaginatedPackages(link.URL)
-			}
-		}
-	}
-	return rv, nil
+	return c.GetPaginatedPackagesContext(context.Background(), endpoint)
 }
 
-// ExtractPaginationHeaders - Extract Paginated Object from the http.Headers
-func ExtractPaginationHeaders(h *http.Header, p *Paginated) error {
-	header := h.Get("Total")
-	total, err := strconv.Atoi(header)
-	if err != nil {
-		return err
-	}
-	p.Total = total
-
-	header = h.Get("Per-Page")
-	perPage, err := strconv.Atoi(header)
+// GetPaginatedPackagesContext is like GetPaginatedPackages, but with a context.
+func (c *Client) GetPaginatedPackagesContext(ctx context.Context, endpoint string) (*PaginatedPackages, error) {
+	page, err := GetPage[*Package](ctx, c, endpoint, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	p.PerPage = perPage
+	return paginatedPackages(page), nil
+}
 
-	header = h.Get("Max-Per-Page")
-	maxPerPage, err := strconv.Atoi(header)
-	if err != nil {
-		return err
+// paginatedPackages converts a Page of packages to PaginatedPackages
+func paginatedPackages(page *Page[*Package]) *PaginatedPackages {
+	rv := &PaginatedPackages{Packages: page.Items, Paginated: page.Paginated}
+	if page.Next != nil {
+		rv.Next = func() (*PaginatedPackages, error) {
+			next, err := page.Next()
+			if err != nil {
+				return nil, err
+			}
+			return paginatedPackages(next), nil
+		}
 	}
-	p.MaxPerPage = maxPerPage
-	return nil
+	return rv
 }
 
 // PaginatedAll - Get the list of all Packages from a repo using PaginatedPackages
 // The first PaginatedPackages object is the first page of responses.
 // To get subsequent pages, call PaginatedPackages.Next() if it is non-nil
 func (c *Client) PaginatedAll(repo string) (*PaginatedPackages, error) {
-	endpoint := fmt.Sprintf("%s/repos/%s/packages.json", ServiceURL, repo)
-	return c.GetPaginatedPackages(endpoint)
+	return c.PaginatedAllContext(context.Background(), repo)
+}
+
+// PaginatedAllContext is like PaginatedAll, but with a context.
+func (c *Client) PaginatedAllContext(ctx context.Context, repo string) (*PaginatedPackages, error) {
+	endpoint := c.apiURL("repos/%s/packages.json", repo)
+	return c.GetPaginatedPackagesContext(ctx, endpoint)
 }
 
 // Promote - Promote Package to repo
 func (c *Client) Promote(p *Package, repo string) error {
-	endpoint := fmt.Sprintf("%s/%s", ServiceBaseURL, p.PromoteURL)
+	return c.PromoteContext(context.Background(), p, repo)
+}
+
+// PromoteContext is like Promote, but with a context.
+func (c *Client) PromoteContext(ctx context.Context, p *Package, repo string) error {
+	endpoint := c.resolveURL(p.PromoteURL)
 	form := url.Values{}
 	form.Add("destination", repo)
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
-	if err != nil {
-		return err
-	}
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	req, err := c.newRequest(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	return decodeResponse(resp, &struct{}{})
+	return c.do(req, &struct{}{})
 }
 
 // Distributions - struct to represent how packagecloud.io handles distributions
@@ -335,21 +500,18 @@ type DistributionVersion struct {
 
 // Distributions - retrieve all distribution descriptions
 func (c *Client) Distributions() (*Distributions, error) {
-	endpoint := fmt.Sprintf("%s/%s", ServiceURL, "distributions.json")
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	return c.DistributionsContext(context.Background())
+}
+
+// DistributionsContext is like Distributions, but with a context.
+func (c *Client) DistributionsContext(ctx context.Context) (*Distributions, error) {
+	endpoint := c.apiURL("distributions.json")
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 	distributions := &Distributions{}
-	err = decodeResponse(resp, distributions)
+	err = c.do(req, distributions)
 	if err != nil {
 		return nil, err
 	}
@@ -357,9 +519,16 @@ func (c *Client) Distributions() (*Distributions, error) {
 }
 
 // SupportedDistros - return a map of distro strings like "ubuntu/xenial" to distro ids.
+// Deprecated: the ids of distros with packages of several types, like deb and dsc, collide in the map,
+// use DistroResolver instead.
 func (c *Client) SupportedDistros() (map[string]int, error) {
+	return c.SupportedDistrosContext(context.Background())
+}
+
+// SupportedDistrosContext is like SupportedDistros, but with a context.
+func (c *Client) SupportedDistrosContext(ctx context.Context) (map[string]int, error) {
 	rv := make(map[string]int, 256)
-	d, err := c.Distributions()
+	d, err := c.CachedDistributionsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -382,28 +551,15 @@ func (c *Client) SupportedDistros() (map[string]int, error) {
 }
 
 // Exists - Check to see if <repo>/<distro>/packageFilename exists in packagecloud.io
+// When it can't be told, an error is returned rather than false, e.g. one matching ErrUnauthorized
+// for an invalid token, ErrForbidden for a repo the token has no access to, or an *APIError for
+// server errors. See LookupPackage to get the package too.
 func (c *Client) Exists(repo, distro, packageFilename string) (bool, error) {
-	endpoint := fmt.Sprintf("%s/%s/packages/%s/%s", ServiceBaseURL, repo, distro, packageFilename)
-
-	req, err := http.NewRequest("HEAD", endpoint, nil)
-	if err != nil {
-		return false, err
-	}
-
-	req.SetBasicAuth(c.Token, "")
-	req.Header.Add("User-Agent", UserAgent)
+	return c.ExistsContext(context.Background(), repo, distro, packageFilename)
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		if err.Error() == "HTTP status: Not Found" {
-			return false, nil
-		}
-		return false, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return false, nil
-	}
-	return true, nil
+// ExistsContext is like Exists, but with a context.
+func (c *Client) ExistsContext(ctx context.Context, repo, distro, packageFilename string) (bool, error) {
+	_, exists, err := c.LookupPackageContext(ctx, repo, distro, packageFilename)
+	return exists, err
 }
@@ -0,0 +1,67 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"time"
+)
+
+// Repository - packagecloud.io Repository structure
+// See for detailed description of fields: https://packagecloud.io/docs/api#object_Repository
+type Repository struct {
+	Name              string    `json:"name"`
+	CreatedAt         time.Time `json:"created_at"`
+	URL               string    `json:"url"`
+	LastPushHuman     string    `json:"last_push_human"`
+	PackageCountHuman string    `json:"package_count_human"`
+	Private           bool      `json:"private"`
+	FQName            string    `json:"fqname"`
+}
+
+// Repositories - iterate over the repositories of the user owning the API token. opts may be nil.
+func (c *Client) Repositories(opts *ListOptions) *Iterator[*Repository] {
+	return c.RepositoriesContext(context.Background(), opts)
+}
+
+// RepositoriesContext is like Repositories, but with a context.
+func (c *Client) RepositoriesContext(ctx context.Context, opts *ListOptions) *Iterator[*Repository] {
+	return NewIterator[*Repository](ctx, c, c.apiURL("repos.json"), opts)
+}
+
+// Repository - retrieve the repository named repo (e.g. youruser/repository)
+func (c *Client) Repository(repo string) (*Repository, error) {
+	return c.RepositoryContext(context.Background(), repo)
+}
+
+// RepositoryContext is like Repository, but with a context.
+func (c *Client) RepositoryContext(ctx context.Context, repo string) (*Repository, error) {
+	req, err := c.newRequest(ctx, "GET", c.apiURL("repos/%s.json", repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	repository := &Repository{}
+	err = c.do(req, repository)
+	if err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+// CreateRepository - create a repository named name for the user owning the API token
+func (c *Client) CreateRepository(name string, private bool) (*Repository, error) {
+	return c.CreateRepositoryContext(context.Background(), name, private)
+}
+
+// CreateRepositoryContext is like CreateRepository, but with a context.
+func (c *Client) CreateRepositoryContext(ctx context.Context, name string, private bool) (*Repository, error) {
+	repository := &Repository{}
+	err := c.postJSON(ctx, c.apiURL("repos.json"), map[string]interface{}{
+		"repository": map[string]interface{}{
+			"name":    name,
+			"private": private,
+		},
+	}, repository)
+	if err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
@@ -0,0 +1,212 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// packageTypes - the package type of each package file extension
+var packageTypes = map[string]string{
+	".deb":  "deb",
+	".udeb": "deb",
+	".ddeb": "deb",
+	".dsc":  "dsc",
+	".rpm":  "rpm",
+	".gem":  "gem",
+	".whl":  "python",
+	".egg":  "python",
+	".jar":  "java",
+	".aar":  "java",
+	".war":  "java",
+}
+
+// PackageType - the package type of the package file filename, from its extension: deb, dsc, rpm
+// (source rpms included), gem, python or java. Empty if the extension isn't known.
+func PackageType(filename string) string {
+	return packageTypes[strings.ToLower(path.Ext(filename))]
+}
+
+// DistroResolver - resolves distro names, like "ubuntu/xenial", to the distro version ids packages are pushed with.
+// The same name has a different id for each package type, e.g. for deb and dsc packages.
+type DistroResolver struct {
+	// ids - distro version id by package type and distro name
+	ids map[string]map[string]int
+}
+
+// NewDistroResolver - a DistroResolver for the distributions d
+func NewDistroResolver(d *Distributions) *DistroResolver {
+	r := &DistroResolver{ids: make(map[string]map[string]int)}
+	for pkgType, distributions := range map[string][]Distribution{"deb": d.Deb, "dsc": d.Dsc, "rpm": d.Rpm} {
+		ids := make(map[string]int)
+		for _, dist := range distributions {
+			for _, v := range dist.Versions {
+				ids[dist.IndexName+"/"+v.IndexName] = v.ID
+			}
+		}
+		r.ids[pkgType] = ids
+	}
+	return r
+}
+
+// DistroResolver - a DistroResolver for the distributions of packagecloud.io, see CachedDistributions
+func (c *Client) DistroResolver() (*DistroResolver, error) {
+	return c.DistroResolverContext(context.Background())
+}
+
+// DistroResolverContext is like DistroResolver, but with a context.
+func (c *Client) DistroResolverContext(ctx context.Context) (*DistroResolver, error) {
+	d, err := c.CachedDistributionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewDistroResolver(d), nil
+}
+
+// Resolve - the id of distro for packages of type pkgType, see PackageType.
+// With an empty pkgType, distro must have the same id for all the package types it exists for.
+// Unknown distros are reported with an *UnknownDistroError.
+func (r *DistroResolver) Resolve(pkgType, distro string) (int, error) {
+	if pkgType != "" {
+		if id, ok := r.ids[pkgType][distro]; ok {
+			return id, nil
+		}
+		return 0, &UnknownDistroError{Type: pkgType, Distro: distro, Suggestions: suggest(distro, r.ids[pkgType])}
+	}
+	var types []string
+	id := 0
+	for _, t := range r.types() {
+		if i, ok := r.ids[t][distro]; ok {
+			if len(types) > 0 && i != id {
+				return 0, fmt.Errorf("distro %s has a different id for %s packages, the package type must be known", distro, strings.Join(append(types, t), " and "))
+			}
+			types, id = append(types, t), i
+		}
+	}
+	if len(types) == 0 {
+		all := make(map[string]int)
+		for _, ids := range r.ids {
+			for name, id := range ids {
+				all[name] = id
+			}
+		}
+		return 0, &UnknownDistroError{Distro: distro, Suggestions: suggest(distro, all)}
+	}
+	return id, nil
+}
+
+// ResolveFile - the id of distro for the package file filename, see Resolve and PackageType
+func (r *DistroResolver) ResolveFile(filename, distro string) (int, error) {
+	return r.Resolve(PackageType(filename), distro)
+}
+
+// Match - the names of the distros for packages of type pkgType matching pattern, sorted. pattern is matched
+// like with path.Match, e.g. "ubuntu/*" or "el/[78]". With an empty pkgType, the distros of all types are matched.
+func (r *DistroResolver) Match(pkgType, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid distro pattern %s: %s", pattern, err)
+	}
+	matched := make(map[string]bool)
+	for _, t := range r.types() {
+		if pkgType != "" && t != pkgType {
+			continue
+		}
+		for name := range r.ids[t] {
+			if ok, _ := path.Match(pattern, name); ok {
+				matched[name] = true
+			}
+		}
+	}
+	rv := make([]string, 0, len(matched))
+	for name := range matched {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
+
+// types - the package types of r, sorted
+func (r *DistroResolver) types() []string {
+	var rv []string
+	for t := range r.ids {
+		rv = append(rv, t)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// UnknownDistroError - a distro name that isn't one of the distros of packagecloud.io
+type UnknownDistroError struct {
+	// Type - the package type the distro was looked up for, empty for all
+	Type string
+	// Distro - the distro name
+	Distro string
+	// Suggestions - the known distro names closest to Distro, the closest first
+	Suggestions []string
+}
+
+func (e *UnknownDistroError) Error() string {
+	msg := "invalid distro name: " + e.Distro
+	if e.Type != "" {
+		msg += " for " + e.Type + " packages"
+	}
+	if len(e.Suggestions) > 0 {
+		msg += ", did you mean " + strings.Join(e.Suggestions, " or ") + "?"
+	}
+	return msg
+}
+
+// maxSuggestions - the number of suggestions of UnknownDistroError
+const maxSuggestions = 3
+
+// suggest - the names closest to distro: the ones within a few typos,
+// and the ones of the same version of another distro, like "ubuntu/xenial" for "xenial"
+func suggest(distro string, names map[string]int) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	maxDistance := len(distro)/4 + 1
+	var candidates []candidate
+	for name := range names {
+		d := levenshtein(distro, name)
+		if d > maxDistance && path.Base(name) != path.Base(distro) {
+			continue
+		}
+		candidates = append(candidates, candidate{name, d})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	var rv []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		rv = append(rv, candidates[i].name)
+	}
+	return rv
+}
+
+// levenshtein - the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,149 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with transient errors are retried.
+//
+// Connection errors and 500, 502, 503 and 504 responses are retried for
+// idempotent requests (GET, HEAD, PUT, DELETE, OPTIONS) only, unless
+// RetryNonIdempotent is set. 429 Too Many Requests is always retried, as the
+// server did not act on the request.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried. Zero disables retries.
+	MaxRetries int
+	// MinWait is the wait before the first retry; it doubles on each retry.
+	MinWait time.Duration
+	// MaxWait caps the wait between two attempts. If the server asks for a
+	// longer wait with Retry-After or X-RateLimit-Reset, the request is not retried.
+	MaxWait time.Duration
+	// RetryNonIdempotent allows retrying POST requests, like package uploads,
+	// even though the server may already have acted on them.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is the RetryPolicy of clients created with New.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinWait:    500 * time.Millisecond,
+	MaxWait:    30 * time.Second,
+}
+
+// WithRetryPolicy sets the RetryPolicy of the client.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) error {
+		c.retry = p
+		return nil
+	}
+}
+
+// idempotent reports whether req may safely be sent more than once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// wait returns how long to wait before retrying req after the given attempt
+// produced resp or err, and whether it should be retried at all.
+func (p RetryPolicy) wait(req *http.Request, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body was consumed and can't be sent again
+		return 0, false
+	}
+	safe := idempotent(req) || p.RetryNonIdempotent
+	if err != nil {
+		return p.backoff(attempt), safe
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !safe {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if d, ok := serverWait(resp.Header); ok {
+		return d, d <= p.MaxWait
+	}
+	return p.backoff(attempt), true
+}
+
+// backoff returns a jittered, exponentially growing wait for attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinWait
+	for i := 0; i < attempt && d < p.MaxWait; i++ {
+		d *= 2
+	}
+	if d > p.MaxWait {
+		d = p.MaxWait
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Wait somewhere between d/2 and d, so that concurrent clients spread out
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// serverWait extracts the wait asked for by the server from the Retry-After
+// or X-RateLimit-* headers.
+func serverWait(h http.Header) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(time.Until(t)), true
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		// Large values are a Unix time, small ones a number of seconds
+		if reset > 1000000000 {
+			return nonNegative(time.Until(time.Unix(reset, 0))), true
+		}
+		return time.Duration(reset) * time.Second, true
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// drain discards and closes the body of a response that is about to be retried,
+// so that its connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
@@ -0,0 +1,337 @@
+// Package rpmfile reads the metadata of RPM packages (.rpm files, binary or source),
+// without any external tool.
+// See https://rpm-software-management.github.io/rpm/manual/format.html
+package rpmfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	leadLength   = 96
+	leadMagic    = "\xed\xab\xee\xdb"
+	headerMagic  = "\x8e\xad\xe8\x01"
+	leadSource   = 1
+	maxIndexSize = 1 << 20
+	maxStoreSize = 256 << 20
+)
+
+// Header tags
+const (
+	tagName           = 1000
+	tagVersion        = 1001
+	tagRelease        = 1002
+	tagEpoch          = 1003
+	tagSummary        = 1004
+	tagLicense        = 1014
+	tagArch           = 1022
+	tagSourceRPM      = 1044
+	tagProvideName    = 1047
+	tagRequireFlags   = 1048
+	tagRequireName    = 1049
+	tagRequireVersion = 1050
+	tagSourcePackage  = 1106
+	tagProvideFlags   = 1112
+	tagProvideVersion = 1113
+)
+
+// Header entry types
+const (
+	typeInt32       = 4
+	typeString      = 6
+	typeStringArray = 8
+	typeI18NString  = 9
+)
+
+// Dependency flags
+const (
+	senseLess    = 1 << 1
+	senseGreater = 1 << 2
+	senseEqual   = 1 << 3
+)
+
+// Package - the metadata of a .rpm
+type Package struct {
+	Name    string `json:"name"`
+	Epoch   int    `json:"epoch"`
+	Version string `json:"version"`
+	Release string `json:"release"`
+	// Arch - the architecture of the package, like x86_64 or noarch. That of the build for source packages.
+	Arch    string `json:"arch"`
+	Summary string `json:"summary,omitempty"`
+	License string `json:"license,omitempty"`
+	// Source - whether this is a source package
+	Source bool `json:"source"`
+	// SourceRPM - the source package a binary package was built from
+	SourceRPM string `json:"source_rpm,omitempty"`
+	// Requires - the requirements of the package, like "libc.so.6()(64bit)" or "bash >= 4.0"
+	Requires []string `json:"requires,omitempty"`
+	// Provides - the capabilities the package provides, like "vpp = 17.10-release.el7"
+	Provides []string `json:"provides,omitempty"`
+}
+
+// EVR - the epoch, version and release of the package, like "1:17.10-release.el7", without epoch when 0
+func (p *Package) EVR() string {
+	evr := p.Version + "-" + p.Release
+	if p.Epoch != 0 {
+		evr = strconv.Itoa(p.Epoch) + ":" + evr
+	}
+	return evr
+}
+
+// Filename - the canonical filename of the package, name-version-release.arch.rpm,
+// or name-version-release.src.rpm for source packages
+func (p *Package) Filename() string {
+	arch := p.Arch
+	if p.Source {
+		arch = "src"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s.rpm", p.Name, p.Version, p.Release, arch)
+}
+
+// Open - the metadata of the .rpm at path
+func Open(path string) (*Package, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return p, nil
+}
+
+// Read - the metadata of the .rpm read from r. Only the lead, signature and header are read,
+// not the payload.
+func Read(r io.Reader) (*Package, error) {
+	br := bufio.NewReader(r)
+	lead := make([]byte, leadLength)
+	if _, err := io.ReadFull(br, lead); err != nil || string(lead[:4]) != leadMagic {
+		return nil, errors.New("rpmfile: not an rpm: no lead")
+	}
+	// The signature header is padded to a multiple of 8 bytes
+	if _, err := readHeader(br, true); err != nil {
+		return nil, fmt.Errorf("rpmfile: signature: %s", err)
+	}
+	h, err := readHeader(br, false)
+	if err != nil {
+		return nil, fmt.Errorf("rpmfile: header: %s", err)
+	}
+	p := &Package{
+		Name:      h.string(tagName),
+		Version:   h.string(tagVersion),
+		Release:   h.string(tagRelease),
+		Arch:      h.string(tagArch),
+		Summary:   h.string(tagSummary),
+		License:   h.string(tagLicense),
+		SourceRPM: h.string(tagSourceRPM),
+	}
+	if epoch := h.int32s(tagEpoch); len(epoch) > 0 {
+		p.Epoch = int(epoch[0])
+	}
+	_, sourcePackage := h.entries[tagSourcePackage]
+	p.Source = binary.BigEndian.Uint16(lead[6:8]) == leadSource || sourcePackage || p.SourceRPM == ""
+	p.Requires = dependencies(h.strings(tagRequireName), h.int32s(tagRequireFlags), h.strings(tagRequireVersion))
+	p.Provides = dependencies(h.strings(tagProvideName), h.int32s(tagProvideFlags), h.strings(tagProvideVersion))
+	if p.Name == "" || p.Version == "" {
+		return nil, errors.New("rpmfile: no name or version in header")
+	}
+	return p, nil
+}
+
+// entry - an index entry of a header
+type entry struct {
+	typ    uint32
+	offset uint32
+	count  uint32
+}
+
+// header - a header structure, signature or main header
+type header struct {
+	entries map[uint32]entry
+	store   []byte
+}
+
+// readHeader - the next header structure of r, padded to 8 bytes if pad is set
+func readHeader(r io.Reader, pad bool) (*header, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, err
+	}
+	if string(intro[:4]) != headerMagic {
+		return nil, errors.New("bad magic")
+	}
+	nindex := binary.BigEndian.Uint32(intro[8:12])
+	hsize := binary.BigEndian.Uint32(intro[12:16])
+	if nindex > maxIndexSize || hsize > maxStoreSize {
+		return nil, errors.New("too large")
+	}
+	index := make([]byte, 16*nindex)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, err
+	}
+	h := &header{entries: make(map[uint32]entry, nindex), store: make([]byte, hsize)}
+	if _, err := io.ReadFull(r, h.store); err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < nindex; i++ {
+		e := index[16*i:]
+		h.entries[binary.BigEndian.Uint32(e[0:4])] = entry{
+			typ:    binary.BigEndian.Uint32(e[4:8]),
+			offset: binary.BigEndian.Uint32(e[8:12]),
+			count:  binary.BigEndian.Uint32(e[12:16]),
+		}
+	}
+	if pad && hsize%8 != 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(8-hsize%8)); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// strings - the strings of the entry tag, nil if there's no such entry of a string type
+func (h *header) strings(tag uint32) []string {
+	e, ok := h.entries[tag]
+	if !ok || (e.typ != typeString && e.typ != typeStringArray && e.typ != typeI18NString) || int(e.offset) > len(h.store) {
+		return nil
+	}
+	count := int(e.count)
+	if e.typ == typeString {
+		count = 1
+	}
+	data := h.store[e.offset:]
+	var rv []string
+	for i := 0; i < count; i++ {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return rv
+		}
+		rv = append(rv, string(data[:end]))
+		data = data[end+1:]
+	}
+	return rv
+}
+
+// string - the first string of the entry tag, empty if there's none
+func (h *header) string(tag uint32) string {
+	if s := h.strings(tag); len(s) > 0 {
+		return s[0]
+	}
+	return ""
+}
+
+// int32s - the integers of the entry tag, nil if there's no such entry of type INT32
+func (h *header) int32s(tag uint32) []int32 {
+	e, ok := h.entries[tag]
+	if !ok || e.typ != typeInt32 || uint64(e.offset)+4*uint64(e.count) > uint64(len(h.store)) {
+		return nil
+	}
+	rv := make([]int32, e.count)
+	for i := range rv {
+		rv[i] = int32(binary.BigEndian.Uint32(h.store[int(e.offset)+4*i:]))
+	}
+	return rv
+}
+
+// dependencies - the dependencies with the given names, flags and versions, like "bash >= 4.0"
+func dependencies(names []string, flags []int32, versions []string) []string {
+	var rv []string
+	for i, name := range names {
+		dep := name
+		if i < len(versions) && versions[i] != "" && i < len(flags) {
+			op := ""
+			if flags[i]&senseLess != 0 {
+				op += "<"
+			}
+			if flags[i]&senseGreater != 0 {
+				op += ">"
+			}
+			if flags[i]&senseEqual != 0 {
+				op += "="
+			}
+			if op != "" {
+				dep += " " + op + " " + versions[i]
+			}
+		}
+		rv = append(rv, dep)
+	}
+	return rv
+}
+
+// distTagRegexp - matches the dist tags of release strings, like .el7, .el8_4 or .fc30
+var distTagRegexp = regexp.MustCompile(`\.(el|fc)(\d+)(?:[._]|$)`)
+
+// elDistros - the packagecloud.io distros of Enterprise Linux rebuilds, whose packages are tagged elN
+var elDistros = map[string]bool{
+	"el":         true,
+	"ol":         true,
+	"scientific": true,
+	"centos":     true,
+	"rhel":       true,
+	"rocky":      true,
+	"almalinux":  true,
+}
+
+// DistTag - the distro and version the dist tag of the release of the package is for,
+// like "el/7" for .el7 or "fedora/30" for .fc30. Empty when there's no known dist tag.
+func (p *Package) DistTag() string {
+	m := distTagRegexp.FindAllStringSubmatch(p.Release, -1)
+	if len(m) == 0 {
+		return ""
+	}
+	// The dist tag usually ends the release, like in 1.git1234.el7
+	tag := m[len(m)-1]
+	if tag[1] == "fc" {
+		return "fedora/" + tag[2]
+	}
+	return "el/" + tag[2]
+}
+
+// DistroMismatchError - a package whose dist tag is for another distro than the one it's pushed to
+type DistroMismatchError struct {
+	Filename string
+	DistTag  string
+	Distro   string
+}
+
+func (e *DistroMismatchError) Error() string {
+	return fmt.Sprintf("%s is built for %s, not %s", e.Filename, e.DistTag, e.Distro)
+}
+
+// CheckDistro - checks the dist tag of the package against distro, like "el/7", which it's pushed to.
+// Packages tagged elN fit all the Enterprise Linux rebuilds of version N. Returns a *DistroMismatchError
+// when they don't match, nil when they do or the package has no known dist tag.
+func (p *Package) CheckDistro(distro string) error {
+	tag := p.DistTag()
+	if tag == "" {
+		return nil
+	}
+	tagName, tagVersion := split(tag)
+	name, version := split(distro)
+	// Versions of EL distros can be minor versions too, like ol/7.6
+	major := strings.SplitN(version, ".", 2)[0]
+	if major == tagVersion && (name == tagName || (tagName == "el" && elDistros[name])) {
+		return nil
+	}
+	return &DistroMismatchError{Filename: p.Filename(), DistTag: tag, Distro: distro}
+}
+
+func split(distro string) (string, string) {
+	parts := strings.SplitN(distro, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
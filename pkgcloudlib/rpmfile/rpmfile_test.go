@@ -0,0 +1,123 @@
+package rpmfile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		file     string
+		want     Package
+		evr      string
+		filename string
+		distTag  string
+	}{
+		{
+			file:     "testdata/hello-1.0-1.x86_64.rpm",
+			want:     Package{Name: "hello", Version: "1.0", Release: "1", Arch: "x86_64", SourceRPM: "hello-1.0-1.src.rpm"},
+			evr:      "1.0-1",
+			filename: "hello-1.0-1.x86_64.rpm",
+		},
+		{
+			file:     "testdata/hello-1.0-1.el7.x86_64.rpm",
+			want:     Package{Name: "hello", Version: "1.0", Release: "1.el7", Arch: "x86_64", SourceRPM: "hello-1.0-1.el7.src.rpm"},
+			evr:      "1.0-1.el7",
+			filename: "hello-1.0-1.el7.x86_64.rpm",
+			distTag:  "el/7",
+		},
+		{
+			file:     "testdata/hello-2.0-3.fc30.noarch.rpm",
+			want:     Package{Name: "hello", Epoch: 4, Version: "2.0", Release: "3.fc30", Arch: "noarch", SourceRPM: "hello-2.0-3.fc30.src.rpm"},
+			evr:      "4:2.0-3.fc30",
+			filename: "hello-2.0-3.fc30.noarch.rpm",
+			distTag:  "fedora/30",
+		},
+		{
+			file:     "testdata/hello-1.0-1.el8_4.src.rpm",
+			want:     Package{Name: "hello", Version: "1.0", Release: "1.el8_4", Arch: "x86_64", Source: true},
+			evr:      "1.0-1.el8_4",
+			filename: "hello-1.0-1.el8_4.src.rpm",
+			distTag:  "el/8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			p, err := Open(tt.file)
+			if err != nil {
+				t.Fatalf("Open(%s): %s", tt.file, err)
+			}
+			got := *p
+			got.Summary, got.License, got.Requires, got.Provides = "", "", nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open(%s) = %+v, want %+v", tt.file, got, tt.want)
+			}
+			if p.Summary != "a test package" || p.License != "MIT" {
+				t.Errorf("Summary, License = %q, %q", p.Summary, p.License)
+			}
+			wantRequires := []string{"bash >= 4.0", "libc.so.6()(64bit)", "rpmlib(CompressedFileNames) <= 3.0.4-1"}
+			if !reflect.DeepEqual(p.Requires, wantRequires) {
+				t.Errorf("Requires = %q, want %q", p.Requires, wantRequires)
+			}
+			if len(p.Provides) != 2 || p.Provides[0] != "hello = "+p.Version+"-"+p.Release {
+				t.Errorf("Provides = %q", p.Provides)
+			}
+			if got := p.EVR(); got != tt.evr {
+				t.Errorf("EVR() = %q, want %q", got, tt.evr)
+			}
+			if got := p.Filename(); got != tt.filename {
+				t.Errorf("Filename() = %q, want %q", got, tt.filename)
+			}
+			if got := p.DistTag(); got != tt.distTag {
+				t.Errorf("DistTag() = %q, want %q", got, tt.distTag)
+			}
+		})
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	for _, file := range []string{
+		"testdata/truncated.rpm",
+		"testdata/bad-offsets.rpm",
+		"testdata/bad-index.rpm",
+		"testdata/missing.rpm",
+	} {
+		if p, err := Open(file); err == nil {
+			t.Errorf("Open(%s) = %+v, want an error", file, p)
+		}
+	}
+	if _, err := Read(strings.NewReader("not an rpm")); err == nil {
+		t.Error("Read of text succeeded")
+	}
+}
+
+func TestCheckDistro(t *testing.T) {
+	tests := []struct {
+		release string
+		distro  string
+		ok      bool
+	}{
+		{release: "1.el7", distro: "el/7", ok: true},
+		{release: "1.el7", distro: "centos/7", ok: true},
+		{release: "1.el7_9", distro: "ol/7.6", ok: true},
+		{release: "1.el7", distro: "el/8"},
+		{release: "1.el7", distro: "fedora/7"},
+		{release: "1.fc30", distro: "fedora/30", ok: true},
+		{release: "1.fc30", distro: "fedora/31"},
+		{release: "1", distro: "el/8", ok: true},
+		{release: "1.elegant", distro: "el/8", ok: true},
+	}
+	for _, tt := range tests {
+		p := &Package{Name: "hello", Version: "1.0", Release: tt.release, Arch: "x86_64"}
+		err := p.CheckDistro(tt.distro)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckDistro(%s) of release %s = %v, want ok %v", tt.distro, tt.release, err, tt.ok)
+		}
+		var mismatch *DistroMismatchError
+		if err != nil && !errors.As(err, &mismatch) {
+			t.Errorf("CheckDistro(%s) of release %s = %T, want a *DistroMismatchError", tt.distro, tt.release, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// SearchQuery - what to search packages for
+// See https://packagecloud.io/docs/api#resource_packages_method_search
+type SearchQuery struct {
+	// Query - text searched in the package names, empty for all the packages
+	Query string
+	// Type - package type: deb, rpm, dsc, gem, python, node, java or anyfile. Empty for all types
+	Type string
+	// Dist - distribution, e.g. "ubuntu", or distribution and version, e.g. "ubuntu/xenial"
+	Dist string
+}
+
+// searchFilters - the search filter of each package type
+var searchFilters = map[string]string{
+	"deb": "debs",
+	"rpm": "rpms",
+	"dsc": "dscs",
+	"gem": "gems",
+}
+
+// values - the query parameters of the search.
+// The server only filters by distribution along with a type, and only by
+// distribution name: the rest is up to Search.
+func (q SearchQuery) values() url.Values {
+	v := url.Values{}
+	v.Set("q", q.Query)
+	if q.Type != "" {
+		filter, ok := searchFilters[q.Type]
+		if !ok {
+			filter = q.Type
+		}
+		v.Set("filter", filter)
+		if q.Dist != "" {
+			v.Set("dist", strings.SplitN(q.Dist, "/", 2)[0])
+		}
+	}
+	return v
+}
+
+// matches - whether p matches the parts of q the server doesn't filter on
+func (q SearchQuery) matches(p *Package) bool {
+	if _, ok := searchFilters[q.Type]; ok && p.Type != "" && p.Type != q.Type {
+		return false
+	}
+	if q.Dist == "" {
+		return true
+	}
+	if strings.Contains(q.Dist, "/") {
+		return p.DistroVersion == q.Dist
+	}
+	return strings.HasPrefix(p.DistroVersion, q.Dist+"/")
+}
+
+// Search - returns an iterator over the packages of repo matching q. opts may be nil.
+func (c *Client) Search(repo string, q SearchQuery, opts *ListOptions) PackageIterator {
+	return c.SearchContext(context.Background(), repo, q, opts)
+}
+
+// SearchContext is like Search, but with a context.
+func (c *Client) SearchContext(ctx context.Context, repo string, q SearchQuery, opts *ListOptions) PackageIterator {
+	it := NewIterator[*Package](ctx, c, c.apiURL("repos/%s/search.json", repo)+"?"+q.values().Encode(), opts)
+	it.filter = q.matches
+	return PackageIterator{it}
+}
@@ -0,0 +1,144 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+)
+
+// statsDateFormat - format of the dates of the download statistics API
+const statsDateFormat = "20060102Z"
+
+// DateRange - the period download statistics are computed over. A zero Start
+// or End leaves the range open on that side.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// query returns endpoint restricted to the date range.
+func (r DateRange) query(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	q := u.Query()
+	if !r.Start.IsZero() {
+		q.Set("start_date", r.Start.UTC().Format(statsDateFormat))
+	}
+	if !r.End.IsZero() {
+		q.Set("end_date", r.End.UTC().Format(statsDateFormat))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// DownloadCount - the number of times p was downloaded during r
+// See https://packagecloud.io/docs/api#resource_package_stats
+func (c *Client) DownloadCount(p *Package, r DateRange) (int, error) {
+	return c.DownloadCountContext(context.Background(), p, r)
+}
+
+// DownloadCountContext is like DownloadCount, but with a context.
+func (c *Client) DownloadCountContext(ctx context.Context, p *Package, r DateRange) (int, error) {
+	req, err := c.newRequest(ctx, "GET", r.query(c.resolveURL(p.DownloadCountURL)), nil)
+	if err != nil {
+		return 0, err
+	}
+	var count struct {
+		Value int `json:"value"`
+	}
+	err = c.do(req, &count)
+	if err != nil {
+		return 0, err
+	}
+	return count.Value, nil
+}
+
+// SeriesInterval - the interval of download time series
+type SeriesInterval string
+
+// Intervals of download time series
+const (
+	Daily  SeriesInterval = "daily"
+	Weekly SeriesInterval = "weekly"
+)
+
+// seriesURL - the URL of the interval series, given the URL of the daily one. The others live next
+// to it, as <interval>.json.
+func seriesURL(daily string, interval SeriesInterval) (string, error) {
+	u, err := url.Parse(daily)
+	if err != nil {
+		return "", fmt.Errorf("download series URL %q: %s", daily, err)
+	}
+	dir, file := path.Split(u.Path)
+	if file != string(Daily)+".json" {
+		return "", fmt.Errorf("download series URL %q: not a daily series", daily)
+	}
+	u.Path = dir + string(interval) + ".json"
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// DownloadPoint - the downloads of a package during one interval of a time series
+type DownloadPoint struct {
+	Date  time.Time
+	Count int
+}
+
+// DownloadSeries - the number of times p was downloaded during r, per interval, sorted by date
+func (c *Client) DownloadSeries(p *Package, interval SeriesInterval, r DateRange) ([]DownloadPoint, error) {
+	return c.DownloadSeriesContext(context.Background(), p, interval, r)
+}
+
+// DownloadSeriesContext is like DownloadSeries, but with a context.
+func (c *Client) DownloadSeriesContext(ctx context.Context, p *Package, interval SeriesInterval, r DateRange) ([]DownloadPoint, error) {
+	endpoint, err := seriesURL(c.resolveURL(p.DownloadSeriesURL), interval)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, "GET", r.query(endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+	var series struct {
+		Value map[string]int `json:"value"`
+	}
+	err = c.do(req, &series)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]DownloadPoint, 0, len(series.Value))
+	for date, count := range series.Value {
+		t, err := time.Parse(statsDateFormat, date)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, DownloadPoint{Date: t, Count: count})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+	return points, nil
+}
+
+// Download - a single download of a package
+// See https://packagecloud.io/docs/api#object_DownloadDetail
+type Download struct {
+	DownloadedAt time.Time  `json:"downloaded_at"`
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	Source       string     `json:"source"`
+	ReadToken    *ReadToken `json:"read_token"`
+}
+
+// Downloads - iterate over the downloads of p during r. opts may be nil.
+func (c *Client) Downloads(p *Package, r DateRange, opts *ListOptions) *Iterator[*Download] {
+	return c.DownloadsContext(context.Background(), p, r, opts)
+}
+
+// DownloadsContext is like Downloads, but with a context.
+func (c *Client) DownloadsContext(ctx context.Context, p *Package, r DateRange, opts *ListOptions) *Iterator[*Download] {
+	return NewIterator[*Download](ctx, c, r.query(c.resolveURL(p.DownloadDetailsURL)), opts)
+}
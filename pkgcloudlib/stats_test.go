@@ -0,0 +1,26 @@
+package pkgcloudlib
+
+import "testing"
+
+func TestSeriesURL(t *testing.T) {
+	tests := []struct {
+		daily    string
+		interval SeriesInterval
+		want     string
+		wantErr  bool
+	}{
+		{"https://packagecloud.io/api/v1/repos/a/b/package/deb/ubuntu/xenial/foo/amd64/1.0/1/stats/downloads/series/daily.json", Weekly,
+			"https://packagecloud.io/api/v1/repos/a/b/package/deb/ubuntu/xenial/foo/amd64/1.0/1/stats/downloads/series/weekly.json", false},
+		{"https://packagecloud.io/series/daily.json?x=daily.json", Weekly, "https://packagecloud.io/series/weekly.json?x=daily.json", false},
+		{"https://packagecloud.io/series/daily.json", Daily, "https://packagecloud.io/series/daily.json", false},
+		{"https://packagecloud.io/series/daily", Weekly, "", true},
+		{"", Weekly, "", true},
+		{"%zz", Weekly, "", true},
+	}
+	for _, tt := range tests {
+		got, err := seriesURL(tt.daily, tt.interval)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("seriesURL(%q, %s) = %q, %v, want %q, error %v", tt.daily, tt.interval, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"net/url"
+)
+
+// MasterToken - packagecloud.io master token of a repository, from which read tokens are created
+// See https://packagecloud.io/docs/api#resource_master_tokens
+type MasterToken struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`
+	Value      string            `json:"value"`
+	Paths      map[string]string `json:"paths"`
+	ReadTokens []*ReadToken      `json:"read_tokens"`
+}
+
+// ReadToken - packagecloud.io read token, granting read access to a private repository
+// See https://packagecloud.io/docs/api#resource_read_tokens
+type ReadToken struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MasterTokens - retrieve the master tokens of repo, with their read tokens
+func (c *Client) MasterTokens(repo string) ([]*MasterToken, error) {
+	return c.MasterTokensContext(context.Background(), repo)
+}
+
+// MasterTokensContext is like MasterTokens, but with a context.
+func (c *Client) MasterTokensContext(ctx context.Context, repo string) ([]*MasterToken, error) {
+	req, err := c.newRequest(ctx, "GET", c.apiURL("repos/%s/master_tokens.json", repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*MasterToken
+	err = c.do(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateMasterToken - create a master token named name for repo
+func (c *Client) CreateMasterToken(repo, name string) (*MasterToken, error) {
+	return c.CreateMasterTokenContext(context.Background(), repo, name)
+}
+
+// CreateMasterTokenContext is like CreateMasterToken, but with a context.
+func (c *Client) CreateMasterTokenContext(ctx context.Context, repo, name string) (*MasterToken, error) {
+	form := url.Values{}
+	form.Add("master_token[name]", name)
+	token := &MasterToken{}
+	err := c.postForm(ctx, c.apiURL("repos/%s/master_tokens.json", repo), form, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// DestroyMasterToken - destroy the master token of repo with the given id, and its read tokens
+func (c *Client) DestroyMasterToken(repo string, id int) error {
+	return c.DestroyMasterTokenContext(context.Background(), repo, id)
+}
+
+// DestroyMasterTokenContext is like DestroyMasterToken, but with a context.
+func (c *Client) DestroyMasterTokenContext(ctx context.Context, repo string, id int) error {
+	req, err := c.newRequest(ctx, "DELETE", c.apiURL("repos/%s/master_tokens/%d", repo, id), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, &struct{}{})
+}
+
+// CreateReadToken - create a read token named name from the master token of repo with id masterID
+func (c *Client) CreateReadToken(repo string, masterID int, name string) (*ReadToken, error) {
+	return c.CreateReadTokenContext(context.Background(), repo, masterID, name)
+}
+
+// CreateReadTokenContext is like CreateReadToken, but with a context.
+func (c *Client) CreateReadTokenContext(ctx context.Context, repo string, masterID int, name string) (*ReadToken, error) {
+	form := url.Values{}
+	form.Add("read_token[name]", name)
+	token := &ReadToken{}
+	err := c.postForm(ctx, c.apiURL("repos/%s/master_tokens/%d/read_tokens.json", repo, masterID), form, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// DestroyReadToken - destroy the read token with the given id, created from the master token of repo with id masterID
+func (c *Client) DestroyReadToken(repo string, masterID, id int) error {
+	return c.DestroyReadTokenContext(context.Background(), repo, masterID, id)
+}
+
+// DestroyReadTokenContext is like DestroyReadToken, but with a context.
+func (c *Client) DestroyReadTokenContext(ctx context.Context, repo string, masterID, id int) error {
+	req, err := c.newRequest(ctx, "DELETE", c.apiURL("repos/%s/master_tokens/%d/read_tokens/%d", repo, masterID, id), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, &struct{}{})
+}
@@ -1,45 +1,268 @@
 package upload
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Progress describes how far the upload of a file went.
+type Progress struct {
+	Filename string
+	// Sent is the number of bytes of the file sent so far
+	Sent int64
+	// Total is the size of the file in bytes, or -1 if unknown
+	Total int64
+	// Rate is the average upload rate, in bytes per second
+	Rate float64
+	// Done is set on the last call, once the whole file was sent
+	Done bool
+}
+
+// ProgressFunc is called repeatedly while the file of a Form is being sent.
+// It is called from the goroutine writing the request body.
+type ProgressFunc func(Progress)
+
+// File is the file part of a Form.
+type File struct {
+	// Name is the filename sent to the server
+	Name string
+	// Size is the size of the file in bytes, or -1 if unknown
+	Size int64
+	// Open returns the contents of the file. It is called each time the body
+	// of the request is created, so more than once if the request is retried.
+	Open func() (io.ReadCloser, error)
+	// Once is set when Open can only be called once, so that a request
+	// uploading the file can't be retried
+	Once bool
+}
+
+// Form is a multipart/form-data upload of a single file with extra params.
+type Form struct {
+	Params    map[string]string
+	FieldName string
+	File      File
+	Progress  ProgressFunc
+}
+
 // NewRequest creates a new file upload HTTP request with optional extra params.
 // Based on https://gist.github.com/mattetti/5914158
 func NewRequest(url string, params map[string]string, paramName, path string) (*http.Request, error) {
-	file, err := os.Open(path)
+	return NewRequestWithContext(context.Background(), url, params, paramName, path)
+}
+
+// NewRequestWithContext is like NewRequest, but with a context.
+func NewRequestWithContext(ctx context.Context, url string, params map[string]string, paramName, path string) (*http.Request, error) {
+	file, err := LocalFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	form := &Form{Params: params, FieldName: paramName, File: file}
+	return form.NewRequest(ctx, url)
+}
 
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-	part, err := writer.CreateFormFile(paramName, filepath.Base(path))
+// LocalFile describes the file at path as the file part of a Form.
+func LocalFile(path string) (File, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return nil, err
+		return File{}, err
 	}
-	if _, err = io.Copy(part, file); err != nil {
-		return nil, err
+	return File{
+		Name: filepath.Base(path),
+		Size: info.Size(),
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}, nil
+}
+
+// ReaderFile describes the contents of r as the file part of a Form named
+// name. size is the size of the contents in bytes, or -1 if unknown.
+//
+// The file can only be opened again, e.g. to retry a request, if r is an
+// io.Seeker, in which case it is rewound to its initial offset.
+func ReaderFile(name string, r io.Reader, size int64) File {
+	var offset int64 = -1
+	if seeker, ok := r.(io.Seeker); ok {
+		if o, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			offset = o
+		}
+	}
+	opened := false
+	return File{
+		Name: name,
+		Size: size,
+		Once: offset < 0,
+		Open: func() (io.ReadCloser, error) {
+			if opened {
+				if offset < 0 {
+					return nil, errors.New("upload: " + name + " can't be read again")
+				}
+				if _, err := r.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			opened = true
+			return io.NopCloser(r), nil
+		},
 	}
-	for k, v := range params {
-		if err := writer.WriteField(k, v); err != nil {
+}
+
+// NewRequest creates a POST request uploading f to url.
+//
+// The file is streamed from File.Open while the request is sent instead of
+// being buffered in memory. Unless File.Once is set, the body can be
+// re-created with GetBody, which opens the file again, so that the request
+// can be retried. When the size of the file is known the request has an exact
+// Content-Length, otherwise it is sent chunked.
+func (f *Form) NewRequest(ctx context.Context, url string) (*http.Request, error) {
+	boundary := multipart.NewWriter(nil).Boundary()
+	var mu sync.Mutex
+	var current *formBody
+	open := func() (io.ReadCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if current != nil {
+			// The previous body may still be written, from the same file
+			current.stop()
+		}
+		body, err := f.body(boundary)
+		if err != nil {
 			return nil, err
 		}
+		current = body
+		return body, nil
 	}
-	if err := writer.Close(); err != nil {
+	body, err := open()
+	if err != nil {
 		return nil, err
 	}
-
-	request, err := http.NewRequest("POST", url, &body)
+	request, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
+		body.Close()
 		return nil, err
 	}
+	if !f.File.Once {
+		request.GetBody = open
+	}
+	request.ContentLength = -1
+	if f.File.Size >= 0 {
+		overhead := &countingWriter{}
+		if err := f.write(overhead, boundary, strings.NewReader("")); err != nil {
+			body.Close()
+			return nil, err
+		}
+		request.ContentLength = overhead.n + f.File.Size
+	}
+	writer := multipart.NewWriter(nil)
+	writer.SetBoundary(boundary)
 	request.Header.Add("Content-Type", writer.FormDataContentType())
 	return request, nil
 }
+
+// errBodyReplaced - the error reading a body that was replaced by GetBody
+var errBodyReplaced = errors.New("upload: request body replaced")
+
+// formBody is the encoded form, written on the fly by a goroutine.
+type formBody struct {
+	*io.PipeReader
+	// done is closed once the goroutine is done with the file
+	done chan struct{}
+}
+
+// stop stops writing b, and waits for the goroutine writing it to be done.
+func (b *formBody) stop() {
+	b.CloseWithError(errBodyReplaced)
+	<-b.done
+}
+
+// body opens the file and returns the encoded form, written on the fly
+// by a goroutine.
+func (f *Form) body(boundary string) (*formBody, error) {
+	file, err := f.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	var src io.Reader = file
+	if f.Progress != nil {
+		src = &progressReader{
+			r:        file,
+			progress: f.Progress,
+			current:  Progress{Filename: f.File.Name, Total: f.File.Size},
+			start:    time.Now(),
+		}
+	}
+	pr, pw := io.Pipe()
+	body := &formBody{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(body.done)
+		defer file.Close()
+		pw.CloseWithError(f.write(pw, boundary, src))
+	}()
+	return body, nil
+}
+
+// write encodes the form to w, taking the file contents from file.
+func (f *Form) write(w io.Writer, boundary string, file io.Reader) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(f.Params))
+	for k := range f.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := writer.WriteField(k, f.Params[k]); err != nil {
+			return err
+		}
+	}
+	part, err := writer.CreateFormFile(f.FieldName, f.File.Name)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(part, file); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// progressReader reports the progress of reads from r.
+type progressReader struct {
+	r        io.Reader
+	progress ProgressFunc
+	current  Progress
+	start    time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.current.Sent += int64(n)
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		p.current.Rate = float64(p.current.Sent) / elapsed
+	}
+	p.current.Done = err == io.EOF
+	if n > 0 || p.current.Done {
+		p.progress(p.current)
+	}
+	return n, err
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	return len(b), nil
+}
@@ -0,0 +1,96 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// readFile - the contents of the file part of the multipart form body of req
+func readFile(t *testing.T, req *http.Request, body io.Reader) []byte {
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm: %s", err)
+	}
+	f, err := form.File["package"][0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGetBody(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789"), 100000)
+	form := &Form{
+		Params:    map[string]string{"a": "b"},
+		FieldName: "package",
+		File:      ReaderFile("foo.deb", bytes.NewReader(contents), int64(len(contents))),
+	}
+	req, err := form.NewRequest(context.Background(), "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GetBody == nil {
+		t.Fatal("no GetBody for a seekable reader")
+	}
+	// Like a request failing half way, while the body is still being written
+	if _, err := io.ReadFull(req.Body, make([]byte, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		body, err := req.GetBody()
+		if err != nil {
+			t.Fatalf("GetBody: %s", err)
+		}
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != req.ContentLength {
+			t.Errorf("body of %d bytes, Content-Length %d", n, req.ContentLength)
+		}
+		if got := readFile(t, req, &buf); !bytes.Equal(got, contents) {
+			t.Errorf("retried upload of %d bytes, want %d", len(got), len(contents))
+		}
+	}
+	if _, err := ioutil.ReadAll(req.Body); err == nil {
+		t.Error("the replaced body can still be read")
+	}
+}
+
+func TestReaderFileOnce(t *testing.T) {
+	contents := "not seekable"
+	form := &Form{
+		FieldName: "package",
+		File:      ReaderFile("foo.deb", io.MultiReader(strings.NewReader(contents)), -1),
+	}
+	req, err := form.NewRequest(context.Background(), "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GetBody != nil {
+		t.Error("GetBody set for a reader that can't be read again")
+	}
+	if req.ContentLength != -1 {
+		t.Errorf("Content-Length %d for a file of unknown size", req.ContentLength)
+	}
+	if got := readFile(t, req, req.Body); string(got) != contents {
+		t.Errorf("upload of %q, want %q", got, contents)
+	}
+}
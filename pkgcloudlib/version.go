@@ -0,0 +1,194 @@
+package pkgcloudlib
+
+import (
+	"sort"
+	"strings"
+)
+
+// ComparePackageVersions - compares the epoch, version and release of a and b, with the rules of
+// the package type of a: rpm's for rpm packages, dpkg's otherwise.
+// The result is negative if a is older than b, positive if a is newer, and 0 if they are the same.
+func ComparePackageVersions(a, b *Package) int {
+	if a.Epoch != b.Epoch {
+		if a.Epoch < b.Epoch {
+			return -1
+		}
+		return 1
+	}
+	compare := CompareDebVersions
+	if a.Type == "rpm" {
+		compare = CompareRPMVersions
+	}
+	if rv := compare(a.Version, b.Version); rv != 0 {
+		return rv
+	}
+	return compare(a.Release, b.Release)
+}
+
+// SortPackages - sorts packages from the oldest to the newest version, see ComparePackageVersions
+func SortPackages(packages []*Package) {
+	sort.SliceStable(packages, func(i, j int) bool {
+		return ComparePackageVersions(packages[i], packages[j]) < 0
+	})
+}
+
+// CompareDebVersions - compares two Debian upstream versions or revisions, like dpkg does
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+func CompareDebVersions(a, b string) int {
+	for a != "" || b != "" {
+		// Non digit parts, in which letters sort before non letters and '~' before anything
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ac, bc := debOrder(a), debOrder(b)
+			if ac != bc {
+				return sign(ac - bc)
+			}
+			a, b = a[1:], b[1:]
+		}
+		// Numeric parts
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && b != "" && isDigit(a[0]) && isDigit(b[0]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[0]) - int(b[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+		if a != "" && isDigit(a[0]) {
+			return 1
+		}
+		if b != "" && isDigit(b[0]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+	return 0
+}
+
+// debOrder - the weight of the first character of s in the non digit parts of Debian versions
+func debOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case isAlpha(s[0]):
+		return int(s[0])
+	case s[0] == '~':
+		return -1
+	default:
+		return int(s[0]) + 256
+	}
+}
+
+// CompareRPMVersions - compares two rpm versions or releases, like rpmvercmp does
+func CompareRPMVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	for a != "" || b != "" {
+		a = strings.TrimLeftFunc(a, isRPMSeparator)
+		b = strings.TrimLeftFunc(b, isRPMSeparator)
+
+		// '~' sorts before anything, even the end of the version
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		// '^' sorts after the end of the version, but before anything else
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+
+		// Compare segments of digits, or of letters
+		isNum := isDigit(a[0])
+		segment := isAlpha
+		if isNum {
+			segment = isDigit
+		}
+		i, j := span(a, segment), span(b, segment)
+		sa, sb := a[:i], b[:j]
+		a, b = a[i:], b[j:]
+		if sb == "" {
+			// Segments of different kinds, numbers are newer
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+		if isNum {
+			sa, sb = strings.TrimLeft(sa, "0"), strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				return sign(len(sa) - len(sb))
+			}
+		}
+		if rv := strings.Compare(sa, sb); rv != 0 {
+			return rv
+		}
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a != "":
+		return 1
+	default:
+		return -1
+	}
+}
+
+// isRPMSeparator - whether r separates the segments of rpm versions
+func isRPMSeparator(r rune) bool {
+	if r < 0x80 && (isDigit(byte(r)) || isAlpha(byte(r))) {
+		return false
+	}
+	return r != '~' && r != '^'
+}
+
+// span - the length of the prefix of s made of characters for which f is true
+func span(s string, f func(byte) bool) int {
+	i := 0
+	for i < len(s) && f(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,135 @@
+package pkgcloudlib
+
+import "testing"
+
+func TestCompareDebVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0", want: 0},
+		{a: "1.0", b: "1.1", want: -1},
+		{a: "1.10", b: "1.9", want: 1},
+		{a: "", b: "0", want: 0},
+		{a: "", b: "1", want: -1},
+		// Leading zeros
+		{a: "1.01", b: "1.1", want: 0},
+		{a: "1.001", b: "1.01", want: 0},
+		{a: "007", b: "7", want: 0},
+		// '~' sorts before anything, even the end
+		{a: "1.0~rc1", b: "1.0", want: -1},
+		{a: "1.0~~", b: "1.0~", want: -1},
+		{a: "1.0~~a", b: "1.0~~", want: 1},
+		{a: "1.0~rc1", b: "1.0~rc2", want: -1},
+		{a: "18.10~rc0", b: "18.10~beta", want: 1},
+		// Letters sort before non letters
+		{a: "1.0a", b: "1.0+", want: -1},
+		{a: "1.0a", b: "1.0", want: 1},
+		{a: "1.0+b1", b: "1.0", want: 1},
+		{a: "1.0.1", b: "1.0a", want: 1},
+		// Alphanumeric segments
+		{a: "4ubuntu1", b: "4ubuntu2", want: -1},
+		{a: "4ubuntu10", b: "4ubuntu9", want: 1},
+		{a: "1ubuntu1", b: "1build1", want: 1},
+		{a: "2.3-4", b: "2.3.4", want: -1},
+	}
+	for _, tt := range tests {
+		if got := CompareDebVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareDebVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareDebVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareDebVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareRPMVersions(t *testing.T) {
+	// Mostly from rpm's own rpmvercmp tests
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0", want: 0},
+		{a: "1.0", b: "2.0", want: -1},
+		{a: "2.0.1", b: "2.0", want: 1},
+		{a: "5.5p1", b: "5.5p2", want: -1},
+		{a: "5.5p10", b: "5.5p1", want: 1},
+		{a: "10xyz", b: "10.1xyz", want: -1},
+		{a: "xyz10", b: "xyz10.1", want: -1},
+		{a: "xyz.4", b: "8", want: -1},
+		{a: "5.5p1", b: "5.5.p1", want: 0},
+		{a: "5.6p1", b: "5.5p2", want: 1},
+		{a: "6.0.rc1", b: "6.0", want: 1},
+		{a: "10b2", b: "10a1", want: 1},
+		{a: "1.0aa", b: "1.0a", want: 1},
+		{a: "1b", b: "1.0", want: -1},
+		{a: "a+", b: "a_", want: 0},
+		{a: "+a", b: "_a", want: 0},
+		{a: "+", b: "_", want: 0},
+		// Leading zeros
+		{a: "1.01", b: "1.1", want: 0},
+		{a: "1.010", b: "1.9", want: 1},
+		{a: "20101121", b: "20101122", want: -1},
+		// '~' sorts before anything, even the end
+		{a: "1.0~rc1", b: "1.0", want: -1},
+		{a: "1.0~rc1", b: "1.0~rc2", want: -1},
+		{a: "1.0~rc1~git123", b: "1.0~rc1", want: -1},
+		{a: "1.0~rc1", b: "1.0arc1", want: -1},
+		// '^' sorts after the end, but before anything else
+		{a: "1.0^", b: "1.0", want: 1},
+		{a: "1.0^git1", b: "1.0", want: 1},
+		{a: "1.0^git1", b: "1.0^git2", want: -1},
+		{a: "1.0^git1", b: "1.01", want: -1},
+		{a: "1.0^20160101", b: "1.0.1", want: -1},
+		{a: "1.0~rc1^git1", b: "1.0~rc1", want: 1},
+		// Dist tags
+		{a: "1.el7", b: "1.el7_9", want: -1},
+		{a: "2.el7", b: "10.el7", want: -1},
+	}
+	for _, tt := range tests {
+		if got := CompareRPMVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareRPMVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareRPMVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareRPMVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestComparePackageVersions(t *testing.T) {
+	tests := []struct {
+		a, b Package
+		want int
+	}{
+		{a: Package{Type: "deb", Epoch: 1, Version: "1.0"}, b: Package{Type: "deb", Version: "2.0"}, want: 1},
+		{a: Package{Type: "rpm", Epoch: 0, Version: "9"}, b: Package{Type: "rpm", Epoch: 1, Version: "1"}, want: -1},
+		{a: Package{Type: "deb", Version: "1.0", Release: "4ubuntu10"}, b: Package{Type: "deb", Version: "1.0", Release: "4ubuntu9"}, want: 1},
+		{a: Package{Type: "rpm", Version: "1.0", Release: "1.el7"}, b: Package{Type: "rpm", Version: "1.0", Release: "1.el7"}, want: 0},
+		// dpkg orders separators, rpm ignores them
+		{a: Package{Type: "deb", Version: "1.0+b1"}, b: Package{Type: "deb", Version: "1.0_b1"}, want: -1},
+		{a: Package{Type: "rpm", Version: "1.0+b1"}, b: Package{Type: "rpm", Version: "1.0_b1"}, want: 0},
+		{a: Package{Type: "deb", Version: "1.0~rc1"}, b: Package{Type: "deb", Version: "1.0"}, want: -1},
+	}
+	for _, tt := range tests {
+		if got := ComparePackageVersions(&tt.a, &tt.b); got != tt.want {
+			t.Errorf("ComparePackageVersions(%+v, %+v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortPackages(t *testing.T) {
+	packages := []*Package{
+		{Type: "deb", Version: "1.10"},
+		{Type: "deb", Version: "1.0~rc1"},
+		{Type: "deb", Epoch: 1, Version: "0.1"},
+		{Type: "deb", Version: "1.9"},
+		{Type: "deb", Version: "1.0"},
+	}
+	SortPackages(packages)
+	want := []string{"1.0~rc1", "1.0", "1.9", "1.10", "0.1"}
+	for i, p := range packages {
+		if p.Version != want[i] {
+			t.Errorf("SortPackages: [%d] = %s, want %s", i, p.Version, want[i])
+		}
+	}
+}
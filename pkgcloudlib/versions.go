@@ -0,0 +1,92 @@
+package pkgcloudlib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DistroPackages - returns an iterator over the packages of repo for distro, like "ubuntu/xenial".
+// pkgType is the package type of the distro: deb, dsc or rpm, see Distributions.Types. opts may be nil.
+func (c *Client) DistroPackages(repo, pkgType, distro string, opts *ListOptions) PackageIterator {
+	return c.DistroPackagesContext(context.Background(), repo, pkgType, distro, opts)
+}
+
+// DistroPackagesContext is like DistroPackages, but with a context.
+func (c *Client) DistroPackagesContext(ctx context.Context, repo, pkgType, distro string, opts *ListOptions) PackageIterator {
+	return PackageIterator{NewIterator[*Package](ctx, c, c.apiURL("repos/%s/packages/%s/%s.json", repo, pkgType, distro), opts)}
+}
+
+// PackageVersions - retrieve all the versions of the package name for arch, like "amd64" or "x86_64",
+// in repo for distro, like "ubuntu/xenial", sorted from the oldest to the newest.
+// pkgType is the package type of the distro: deb, dsc or rpm, see Distributions.Types.
+func (c *Client) PackageVersions(repo, pkgType, distro, name, arch string) ([]*Package, error) {
+	return c.PackageVersionsContext(context.Background(), repo, pkgType, distro, name, arch)
+}
+
+// PackageVersionsContext is like PackageVersions, but with a context.
+func (c *Client) PackageVersionsContext(ctx context.Context, repo, pkgType, distro, name, arch string) ([]*Package, error) {
+	endpoint := c.apiURL("repos/%s/package/%s/%s/%s/%s/versions.json", repo, pkgType, distro, name, arch)
+	it := NewIterator[*Package](ctx, c, endpoint, &ListOptions{PerPage: PerPageMax})
+	var rv []*Package
+	for it.Next() {
+		rv = append(rv, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	SortPackages(rv)
+	return rv, nil
+}
+
+// Types - the package types of distro, like "ubuntu/xenial" or just "ubuntu", in the order deb, dsc, rpm
+func (d *Distributions) Types(distro string) []string {
+	parts := strings.SplitN(distro, "/", 2)
+	var rv []string
+	for _, t := range []struct {
+		name          string
+		distributions []Distribution
+	}{{"deb", d.Deb}, {"dsc", d.Dsc}, {"rpm", d.Rpm}} {
+		if hasDistro(t.distributions, parts) {
+			rv = append(rv, t.name)
+		}
+	}
+	return rv
+}
+
+// hasDistro - whether distributions has the distribution parts[0], and its version parts[1] if any
+func hasDistro(distributions []Distribution, parts []string) bool {
+	for _, dist := range distributions {
+		if dist.IndexName != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			return true
+		}
+		for _, v := range dist.Versions {
+			if v.IndexName == parts[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DistroType - the package type of distro, like "ubuntu/xenial": deb, dsc or rpm. Distributions
+// of both deb and dsc packages are taken as deb.
+func (c *Client) DistroType(distro string) (string, error) {
+	return c.DistroTypeContext(context.Background(), distro)
+}
+
+// DistroTypeContext is like DistroType, but with a context.
+func (c *Client) DistroTypeContext(ctx context.Context, distro string) (string, error) {
+	d, err := c.CachedDistributionsContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	types := d.Types(distro)
+	if len(types) == 0 {
+		return "", fmt.Errorf("invalid distro name: %s", distro)
+	}
+	return types[0], nil
+}